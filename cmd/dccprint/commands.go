@@ -0,0 +1,490 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/fgonzalezurriola/dccprint/internal/clipboard"
+	"github.com/fgonzalezurriola/dccprint/internal/components/scripts"
+	"github.com/fgonzalezurriola/dccprint/internal/config"
+	"github.com/fgonzalezurriola/dccprint/internal/history"
+	"github.com/fgonzalezurriola/dccprint/internal/pdf"
+	"github.com/fgonzalezurriola/dccprint/internal/remote"
+)
+
+// Exit codes of the non-interactive commands
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usageText = `Uso:
+  dccprint [directorio]            Abre la interfaz interactiva
+  dccprint print <archivo.pdf> [--printer <impresora>] [--mode <modo>] [--account cuenta] [--pages 1-5,8,10-]
+                                   [--nup 1|2|4|6|9] [--border] [--collapse-overlays]
+                                   [--copies N] [--collate] [--signature N] [--dry-run]
+  dccprint queue [--printer <impresora>] [--account cuenta]
+  dccprint history [--limit N]     Muestra los últimos trabajos enviados
+  dccprint reprint <id>            Vuelve a imprimir un trabajo del historial
+  dccprint config                  Muestra la configuración guardada
+  dccprint config set <clave> <valor>
+                                   Claves: account, printer, mode, nup, copies, collate, signature,
+                                   theme, clipboard, ssh_host, ssh_port, ssh_identity, ssh_jump
+  dccprint version
+
+Impresoras: %s
+Modos: %s
+Se pueden agregar más en ~/.dccprint_printers.json
+`
+
+// usage lists the printers and modes of the registry
+func usage() string {
+	registry := config.LoadRegistry()
+	return fmt.Sprintf(usageText,
+		strings.Join(registry.PrinterNames(), ", "),
+		strings.Join(registry.ModeAliases(), ", "))
+}
+
+// usageError marks errors caused by wrong arguments
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func runCommand(args []string) int {
+	var err error
+	switch args[0] {
+	case "print":
+		err = cmdPrint(args[1:])
+	case "queue":
+		err = cmdQueue(args[1:])
+	case "history":
+		err = cmdHistory(args[1:], os.Stdout)
+	case "reprint":
+		err = cmdReprint(args[1:])
+	case "config":
+		err = cmdConfig(args[1:], os.Stdout)
+	case "version", "--version", "-v":
+		fmt.Printf("dccprint %s (%s, %s)\n", version, commit, date)
+	case "help", "--help", "-h":
+		fmt.Print(usage())
+	default:
+		// dccprint <directorio> opens the TUI browsing that directory
+		if info, statErr := os.Stat(args[0]); statErr == nil && info.IsDir() && len(args) == 1 {
+			return runTUI(args[0])
+		}
+		err = usageError{fmt.Sprintf("comando desconocido: %s", args[0])}
+	}
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var uerr usageError
+	if errors.As(err, &uerr) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n%s", err, usage())
+		return exitUsage
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return exitError
+}
+
+// parseFlags lets flags appear before or after the positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func resolvePrinter(name string) (string, error) {
+	printer, err := config.LoadRegistry().Printer(name)
+	if err != nil {
+		return "", usageError{err.Error()}
+	}
+	return printer.Name, nil
+}
+
+func resolveMode(name string) (string, error) {
+	mode, err := config.LoadRegistry().Mode(name)
+	if err != nil {
+		return "", usageError{err.Error()}
+	}
+	return mode.Name, nil
+}
+
+func resolveNUp(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || !slices.Contains(pdf.NUpSizes, n) {
+		return 0, usageError{fmt.Sprintf("páginas por cara inválidas: %s (usa 1, 2, 4, 6 o 9)", value)}
+	}
+	return n, nil
+}
+
+func resolveSignature(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n%4 != 0 {
+		return 0, usageError{fmt.Sprintf("cuadernillo inválido: %s (usa un múltiplo de 4, o 0 para no dividir)", value)}
+	}
+	return n, nil
+}
+
+func resolveCopies(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > scripts.MaxCopies {
+		return 0, usageError{fmt.Sprintf("copias inválidas: %s (usa un número entre 1 y %d)", value, scripts.MaxCopies)}
+	}
+	return n, nil
+}
+
+func requireAccount(account string) error {
+	if account == "" {
+		return usageError{"no hay cuenta configurada, usa --account o 'dccprint config set account <cuenta>'"}
+	}
+	if err := config.ValidateAccount(account); err != nil {
+		return usageError{err.Error()}
+	}
+	return nil
+}
+
+func cmdPrint(args []string) error {
+	cfg := config.Load()
+	fs := newFlagSet("print")
+	printer := fs.String("printer", cfg.Printer, "")
+	mode := fs.String("mode", cfg.Mode, "")
+	account := fs.String("account", cfg.Account, "")
+	pages := fs.String("pages", "", "")
+	dryRun := fs.Bool("dry-run", false, "")
+	nup := fs.String("nup", strconv.Itoa(max(cfg.NUp, 1)), "")
+	border := fs.Bool("border", cfg.NUpBorder, "")
+	collapse := fs.Bool("collapse-overlays", false, "")
+	copies := fs.String("copies", strconv.Itoa(max(cfg.Copies, 1)), "")
+	collate := fs.Bool("collate", cfg.Collate, "")
+	signature := fs.String("signature", strconv.Itoa(cfg.Signature), "")
+
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	if len(files) != 1 {
+		return usageError{"print necesita exactamente un archivo"}
+	}
+	if err := requireAccount(*account); err != nil {
+		return err
+	}
+	if _, err := os.Stat(files[0]); err != nil {
+		return err
+	}
+
+	job := scripts.NewJob(files[0], cfg)
+	job.Account = *account
+	if job.Printer, err = resolvePrinter(*printer); err != nil {
+		return err
+	}
+	if job.Mode, err = resolveMode(*mode); err != nil {
+		return err
+	}
+	if _, _, err := config.LoadRegistry().Resolve(job.Printer, job.Mode); err != nil {
+		return usageError{err.Error()}
+	}
+	if job.Pages, err = scripts.ParsePageRange(*pages); err != nil {
+		return usageError{err.Error()}
+	}
+	if job.NUp, err = resolveNUp(*nup); err != nil {
+		return err
+	}
+	job.Border = *border
+	job.Collapse = *collapse
+	if job.Copies, err = resolveCopies(*copies); err != nil {
+		return err
+	}
+	job.Collate = *collate
+	if job.Signature, err = resolveSignature(*signature); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		plan, err := job.Explain()
+		if err != nil {
+			return err
+		}
+		fmt.Print(plan)
+		if cfg.Quota != nil {
+			fmt.Printf("Te quedan %d hojas según la última consulta.\n", cfg.Quota.Remaining)
+		}
+		return nil
+	}
+	warnQuota(job)
+
+	client, err := remote.Dial(config.Load().SSH(), job.Account, terminalPrompter{})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return sendJob(client, job)
+}
+
+// warnQuota tells when job needs more paper than the last papel query.
+// The job is sent anyway, the cached quota may be outdated.
+func warnQuota(job scripts.Job) {
+	cached := config.Load().Quota
+	if cached == nil {
+		return
+	}
+	sheets, err := job.EstimateSheets()
+	if err != nil || sheets <= cached.Remaining {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Advertencia: este trabajo usa %d hojas y te quedan %d según la última consulta\n", sheets, cached.Remaining)
+}
+
+// sendJob prints job and tells its id in the queue, when lpq shows it
+func sendJob(client *remote.Client, job scripts.Job) error {
+	id, err := scripts.SendJob(client, job, os.Stdout, nil)
+	if err == nil && id > 0 {
+		fmt.Printf("Trabajo %d en la cola de %s\n", id, job.Printer)
+	}
+	return err
+}
+
+func cmdQueue(args []string) error {
+	cfg := config.Load()
+	fs := newFlagSet("queue")
+	printer := fs.String("printer", cfg.Printer, "")
+	account := fs.String("account", cfg.Account, "")
+
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	if len(rest) != 0 {
+		return usageError{"queue no recibe argumentos"}
+	}
+	if err := requireAccount(*account); err != nil {
+		return err
+	}
+	p, err := config.LoadRegistry().Printer(*printer)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+
+	client, err := remote.Dial(config.Load().SSH(), *account, terminalPrompter{})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return client.Run(p.ListCommand(), nil, os.Stdout)
+}
+
+func cmdHistory(args []string, out io.Writer) error {
+	fs := newFlagSet("history")
+	limit := fs.Int("limit", 20, "")
+
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	if len(rest) != 0 {
+		return usageError{"history no recibe argumentos"}
+	}
+
+	entries, err := history.Load()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No hay trabajos en el historial")
+		return nil
+	}
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[len(entries)-*limit:]
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		fmt.Fprintln(out, entries[i])
+	}
+	return nil
+}
+
+func cmdReprint(args []string) error {
+	if len(args) != 1 {
+		return usageError{"reprint necesita el id de un trabajo del historial"}
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return usageError{fmt.Sprintf("id inválido: %s", args[0])}
+	}
+	entry, err := history.Find(id)
+	if err != nil {
+		return err
+	}
+	job, err := scripts.JobFromEntry(entry)
+	if err != nil {
+		return err
+	}
+	if err := requireAccount(job.Account); err != nil {
+		return err
+	}
+	if _, err := os.Stat(job.File); err != nil {
+		return err
+	}
+	if entry.Changed() {
+		fmt.Fprintf(os.Stderr, "Aviso: %s cambió desde que se imprimió\n", job.File)
+	}
+	warnQuota(job)
+
+	client, err := remote.Dial(config.Load().SSH(), job.Account, terminalPrompter{})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return sendJob(client, job)
+}
+
+func cmdConfig(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(config.Load())
+	}
+	if args[0] != "set" || len(args) != 3 {
+		return usageError{"uso: dccprint config set <clave> <valor>"}
+	}
+
+	key, value := args[1], args[2]
+	switch key {
+	case "account":
+		if err := config.ValidateAccount(value); err != nil {
+			return usageError{err.Error()}
+		}
+		return config.SaveAccount(value)
+	case "printer":
+		printer, err := resolvePrinter(value)
+		if err != nil {
+			return err
+		}
+		return config.SavePrinter(printer)
+	case "mode":
+		mode, err := resolveMode(value)
+		if err != nil {
+			return err
+		}
+		return config.SaveMode(mode)
+	case "theme":
+		return config.SaveTheme(value)
+	case "nup":
+		n, err := resolveNUp(value)
+		if err != nil {
+			return err
+		}
+		return config.SaveLayout(n, config.Load().NUpBorder)
+	case "copies":
+		n, err := resolveCopies(value)
+		if err != nil {
+			return err
+		}
+		return config.SaveCopies(n, config.Load().Collate)
+	case "collate":
+		collate, err := strconv.ParseBool(value)
+		if err != nil {
+			return usageError{fmt.Sprintf("valor inválido para collate: %s (usa true o false)", value)}
+		}
+		return config.SaveCopies(config.Load().Copies, collate)
+	case "signature":
+		n, err := resolveSignature(value)
+		if err != nil {
+			return err
+		}
+		return config.SaveSignature(n)
+	case "clipboard":
+		if _, err := clipboard.New(value); err != nil {
+			return usageError{err.Error()}
+		}
+		return config.SaveClipboard(strings.ToLower(value))
+	case "ssh_host", "ssh_port", "ssh_identity", "ssh_jump":
+		settings, err := setSSH(config.Load().SSH(), key, value)
+		if err != nil {
+			return err
+		}
+		return config.SaveSSH(settings)
+	}
+	return usageError{fmt.Sprintf("clave desconocida: %s", key)}
+}
+
+// setSSH changes one of the connection settings. An empty value goes back
+// to ~/.ssh/config or the default.
+func setSSH(s remote.Settings, key, value string) (remote.Settings, error) {
+	switch key {
+	case "ssh_host":
+		s.Host = value
+	case "ssh_port":
+		s.Port = 0
+		if value != "" {
+			port, err := strconv.Atoi(value)
+			if err != nil {
+				return s, usageError{fmt.Sprintf("puerto inválido: %s", value)}
+			}
+			s.Port = port
+		}
+	case "ssh_identity":
+		s.IdentityFile = value
+	case "ssh_jump":
+		s.JumpHost = value
+	}
+	if err := s.Validate(); err != nil {
+		return s, usageError{err.Error()}
+	}
+	return s, nil
+}
+
+// terminalPrompter asks for the password on the controlling terminal.
+// Without a terminal only key based logins can work.
+type terminalPrompter struct{}
+
+func (terminalPrompter) Prompt(question string, echo bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("se necesita una terminal para ingresar la contraseña, configura una llave SSH para usar dccprint sin terminal")
+	}
+
+	fmt.Fprint(os.Stderr, question+" ")
+	if echo {
+		var answer string
+		_, err := fmt.Fscanln(os.Stdin, &answer)
+		return answer, err
+	}
+	answer, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(answer), err
+}
@@ -9,10 +9,26 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// Set by goreleaser through -ldflags
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
 func main() {
-	p := tea.NewProgram(app.NewModel())
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+	os.Exit(runTUI(""))
+}
+
+// runTUI opens the interactive interface browsing files from dir
+func runTUI(dir string) int {
+	p := tea.NewProgram(app.NewModel(dir))
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v", err)
-		os.Exit(1)
+		return 1
 	}
+	return 0
 }
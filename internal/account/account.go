@@ -15,7 +15,7 @@ func NewManager(t *theme.Theme, cfg config.Config) Manager {
 	ti := textinput.New()
 	ti.Placeholder = "Ingresa el nombre de cuenta (sin @)"
 	ti.Focus()
-	ti.CharLimit = 16
+	ti.CharLimit = config.MaxAccountLength
 	ti.SetValue(cfg.Account)
 	ti.PromptStyle = lipgloss.NewStyle().Foreground(t.Selected)
 	ti.TextStyle = lipgloss.NewStyle().Foreground(t.Header)
@@ -23,7 +23,7 @@ func NewManager(t *theme.Theme, cfg config.Config) Manager {
 	return Manager{AccountInput: ti}
 }
 
-func (a *Manager) SaveAccount() {
+func (a *Manager) SaveAccount() error {
 	account := a.AccountInput.Value()
-	config.SaveAccount(account)
+	return config.SaveAccount(account)
 }
@@ -15,16 +15,16 @@ func NewFreshManager(t *theme.Theme) FreshManager {
 	ti := textinput.New()
 	ti.Placeholder = "Configura tu cuenta (sin @)"
 	ti.Focus()
-	ti.CharLimit = 16
+	ti.CharLimit = config.MaxAccountLength
 	ti.PromptStyle = lipgloss.NewStyle().Foreground(t.Selected)
 	ti.TextStyle = lipgloss.NewStyle().Foreground(t.Header)
 
 	return FreshManager{AccountInput: ti}
 }
 
-func (a *FreshManager) SaveAccount() {
+func (a *FreshManager) SaveAccount() error {
 	account := a.AccountInput.Value()
-	config.SaveAccount(account)
+	return config.SaveAccount(account)
 }
 
 func (a *FreshManager) View() string {
@@ -0,0 +1,65 @@
+package account
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fgonzalezurriola/dccprint/internal/remote"
+	"github.com/fgonzalezurriola/dccprint/internal/theme"
+)
+
+// KeyManager offers to set up an SSH key, so the DCC password is asked
+// one last time instead of on every print
+type KeyManager struct {
+	// Key is the private key found, or the one to generate when Exists is false
+	Key    string
+	Exists bool
+	theme  *theme.Theme
+}
+
+func NewKeyManager(t *theme.Theme) KeyManager {
+	return KeyManager{theme: t}
+}
+
+// Check looks for the key that would be used with the settings s
+func (k *KeyManager) Check(s remote.Settings) {
+	k.Key, k.Exists = remote.FindKey(s), true
+	if k.Key == "" {
+		k.Key, _ = remote.DefaultKeyPath()
+		k.Exists = false
+	}
+}
+
+func (k *KeyManager) SetTheme(t *theme.Theme) {
+	k.theme = t
+}
+
+func (k *KeyManager) View() string {
+	bold := lipgloss.NewStyle().Bold(true).Render("LLAVE SSH")
+	dim := lipgloss.NewStyle().Foreground(k.theme.Unselected)
+	key := lipgloss.NewStyle().Foreground(k.theme.Selected).Render(shortPath(k.Key))
+
+	var info string
+	if k.Exists {
+		info = "Tienes la llave " + key + ". dccprint la puede autorizar en anakena\npara no pedir tu contraseña DCC en cada impresión."
+	} else {
+		info = "No tienes una llave SSH. dccprint puede generar " + key + "\ny autorizarla en anakena para no pedir tu contraseña DCC en cada impresión."
+	}
+	note := dim.Render("Se pedirá la contraseña una última vez y luego se probará la llave.")
+	help := dim.Render("enter: configurar • n: ahora no")
+	return lipgloss.JoinVertical(lipgloss.Left, bold, info, "", note, "", help)
+}
+
+// shortPath shows path relative to the home directory, like ~/.ssh/id_ed25519
+func shortPath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~/" + rest
+	}
+	return path
+}
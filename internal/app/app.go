@@ -1,9 +1,10 @@
 package app
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"path/filepath"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -12,6 +13,7 @@ import (
 	"github.com/fgonzalezurriola/dccprint/internal/components"
 	"github.com/fgonzalezurriola/dccprint/internal/components/scripts"
 	"github.com/fgonzalezurriola/dccprint/internal/config"
+	"github.com/fgonzalezurriola/dccprint/internal/remote"
 	"github.com/fgonzalezurriola/dccprint/internal/theme"
 )
 
@@ -24,19 +26,51 @@ type Model struct {
 	PrintView      components.PrintView
 	PrinterView    components.PrinterView
 	ModeView       components.ModeView
+	LayoutView     components.LayoutView
+	SSHView        components.SSHView
+	RemoteView     components.RemoteView
+	QueueView      components.QueueView
+	HistoryView    components.HistoryView
+	ErrorView      components.ErrorView
 	themeMenu      components.Menu
 	theme          *theme.Theme
 	themeManager   *theme.Manager
 	accountManager account.Manager
 	freshManager   account.FreshManager
+	keyManager     account.KeyManager
 	width          int
 	height         int
 	printCompleted bool
+	conn           *remote.Conn
+	events         chan tea.Msg
+	pendingAnswer  chan string
+	promptReturn   ViewState
+	remoteRunning  bool
+	pendingJobs    []scripts.Job
+	validateGen    int
+	validateCtx    context.Context
+	validateCancel context.CancelFunc
+	validateDone   int
+	validateTotal  int
+	scriptJob      scripts.Job
+	scriptName     string
+	// tracking follows the jobs of the last batch in the queue, trackGen
+	// counts the batches so old polls are dropped. trackAccount is the
+	// account the batch was sent as and trackErrors the polls failed in a row.
+	tracking     []trackedJob
+	trackGen     int
+	trackAccount string
+	trackErrors  int
+	// batchGen counts the batches started, so the paper check of one
+	// cancelled with esc is dropped
+	batchGen int
+	// onboarding is set while the first run goes from the account to the printer
+	onboarding bool
 }
 
 // --- Component Initializers ---
 func newMainMenu(t *theme.Theme) components.Menu {
-	mainMenuItems := []string{"Imprimir PDF", "Configuración de Impresión", "Configurar Cuenta", "Cambiar Theme", "Salir"}
+	mainMenuItems := []string{"Imprimir PDF", "Cola de impresión", "Historial", "Consultar Papel", "Configuración de Impresión", "Configurar Cuenta", "Configurar Conexión", "Configurar Llave SSH", "Cambiar Theme", "Salir"}
 	return components.NewMenu(mainMenuItems, t)
 }
 
@@ -45,8 +79,11 @@ func newThemeMenu(t *theme.Theme) components.Menu {
 	return components.NewMenu(themeMenuItems, t)
 }
 
-func newPrintView(t *theme.Theme) components.PrintView {
-	return components.NewPrintView(scripts.GetPDFFiles(), t)
+func newPrintView(t *theme.Theme, dir string, cfg config.Config) components.PrintView {
+	printView := components.NewPrintView(dir, t)
+	printView.SetRecentDirs(cfg.RecentDirs)
+	printView.SetCopies(cfg.Copies, cfg.Collate)
+	return printView
 }
 
 func newAccountManager(t *theme.Theme, cfg config.Config) account.Manager {
@@ -54,12 +91,18 @@ func newAccountManager(t *theme.Theme, cfg config.Config) account.Manager {
 }
 
 func newPrinterView(t *theme.Theme) components.PrinterView {
-	printerMenuItems := []string{"Salita", "Toqui"}
-	return components.NewPrinterView(printerMenuItems, t)
+	return components.NewPrinterView(config.LoadRegistry().PrinterNames(), t)
 }
 
-func newModeView(t *theme.Theme) components.ModeView {
-	modeMenuItems := []string{"Doble cara, Borde largo (Recomendado)", "Doble cara, Borde corto", "Simple (Reverso en blanco)"}
+// newModeView lists the modes that printer can handle
+func newModeView(t *theme.Theme, printer string) components.ModeView {
+	registry := config.LoadRegistry()
+	var modeMenuItems []string
+	for _, mode := range registry.Modes {
+		if _, _, err := registry.Resolve(printer, mode.Name); err == nil {
+			modeMenuItems = append(modeMenuItems, mode.Name)
+		}
+	}
 	return components.NewModeView(modeMenuItems, t)
 }
 
@@ -73,25 +116,36 @@ func newTextInput(ti textinput.Model, t *theme.Theme, cfg config.Config) {
 }
 
 // --- Model ---
-func NewModel() *Model {
+// NewModel builds the TUI, browsing files from startDir or the working directory
+func NewModel(startDir string) *Model {
 	cfg := config.Load()
 	t := theme.New(cfg.Theme)
 	newTextInput(textinput.New(), t, cfg)
 	themeManager := theme.NewManager(cfg.Theme)
 	vc := NewViewController()
+	events := make(chan tea.Msg, 64)
 
 	model := &Model{
 		config:         cfg,
 		viewController: vc,
 		mainMenu:       newMainMenu(t),
-		PrintView:      newPrintView(t),
+		PrintView:      newPrintView(t, startDir, cfg),
 		PrinterView:    newPrinterView(t),
-		ModeView:       newModeView(t),
+		ModeView:       newModeView(t, cfg.Printer),
+		LayoutView:     components.NewLayoutView(t),
+		SSHView:        components.NewSSHView(t),
+		RemoteView:     components.NewRemoteView(t),
+		QueueView:      components.NewQueueView(t),
+		HistoryView:    components.NewHistoryView(t),
+		ErrorView:      components.NewErrorView(t),
 		themeMenu:      newThemeMenu(t),
 		theme:          t,
 		themeManager:   themeManager,
 		accountManager: newAccountManager(t, cfg),
 		freshManager:   account.NewFreshManager(t),
+		keyManager:     account.NewKeyManager(t),
+		conn:           remote.NewConn(chanPrompter{events: events}),
+		events:         events,
 	}
 
 	if cfg.Account == "" {
@@ -102,7 +156,7 @@ func NewModel() *Model {
 }
 
 func (m *Model) Init() tea.Cmd {
-	return nil
+	return waitForEvent(m.events)
 }
 
 // --- Main  ---
@@ -117,13 +171,61 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.PrintView.SetSize(msg.Width, msg.Height)
 		m.PrinterView.SetSize(msg.Width, msg.Height)
 		m.ModeView.SetSize(msg.Width, msg.Height)
+		m.LayoutView.SetSize(msg.Width, msg.Height)
+		m.SSHView.SetSize(msg.Width, msg.Height)
+		m.RemoteView.SetSize(msg.Width, msg.Height)
+		m.QueueView.SetSize(msg.Width, msg.Height)
+		m.HistoryView.SetSize(msg.Width, msg.Height)
+		m.ErrorView.SetSize(msg.Width, msg.Height)
+
+	case remotePromptMsg, remoteOutputMsg, remoteDoneMsg, batchStatusMsg, quotaCheckMsg, quotaMsg:
+		return m.updateRemoteMsg(msg)
+
+	case queueMsg, queueCancelMsg, queueTickMsg:
+		return m.updateQueueMsg(msg)
+
+	case submittedMsg, trackMsg, trackTickMsg:
+		return m.updateTrackMsg(msg)
+
+	case pdfValidatedMsg:
+		return m.updateValidation(msg)
+
+	case components.PageCountMsg:
+		m.PrintView.SetPageCount(msg)
+		return m, nil
+
+	case scriptMsg:
+		return m.updateScriptMsg(msg)
+
+	case planMsg:
+		return m.updatePlanMsg(msg)
+
+	case scriptDoneMsg:
+		return m.updateScriptDone(msg)
+
+	case keySetupMsg:
+		return m.updateKeySetupMsg(msg)
 
 	// Handle global keybindings
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl+c", "q":
+		case "ctrl+c":
 			return m, tea.Quit
+		case "q":
+			// q is a valid character inside an input
+			if !m.typing() {
+				return m, tea.Quit
+			}
 		case "esc":
+			// Inside the page range input esc only goes back to the file list
+			if m.viewController.Get() == PrintView && m.PrintView.AskingPages() {
+				break
+			}
+			m.cancelPrompt()
+			m.stopValidation()
+			m.pendingJobs = nil
+			m.batchGen++
+			m.onboarding = false
 			if m.viewController.Get() != MainView {
 				m.mainMenu.Reset()
 				m.viewController.Set(MainView)
@@ -141,16 +243,36 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updatePrinterView(msg)
 	case ModeView:
 		return m.updateModeView(msg)
+	case LayoutView:
+		return m.updateLayoutView(msg)
 	case ThemeView:
 		return m.updateThemeView(msg)
 	case AccountView:
 		return m.updateAccountView(msg)
+	case SSHView:
+		return m.updateSSHView(msg)
+	case KeyView:
+		return m.updateKeyView(msg)
 	case FreshView:
 		return m.updateFreshView(msg)
+	case RemoteView:
+		return m.updateRemoteView(msg)
+	case QueueView:
+		return m.updateQueueView(msg)
+	case HistoryView:
+		return m.updateHistoryView(msg)
+	case ErrorView:
+		return m.updateErrorView(msg)
 	}
 	return m, nil
 }
 
+// typing reports whether a text input has the focus, so keys are not shortcuts
+func (m *Model) typing() bool {
+	return m.RemoteView.Prompting() || m.viewController.Get() == SSHView ||
+		(m.viewController.Get() == PrintView && m.PrintView.AskingPages())
+}
+
 // --- Update helpers ---
 func (m *Model) updateMainView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	newMenu, menuCmd := m.mainMenu.Update(msg)
@@ -160,12 +282,32 @@ func (m *Model) updateMainView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
 		switch m.mainMenu.SelectedItem() {
 		case "Imprimir PDF":
+			m.PrintView.Reset()
+			if err := m.PrintView.Err(); err != nil {
+				m.showError(err)
+				return m, nil
+			}
 			m.viewController.Set(PrintView)
+			return m, m.startValidation()
+		case "Cola de impresión":
+			return m, m.openQueue()
+		case "Historial":
+			m.openHistory()
+		case "Consultar Papel":
+			m.RemoteView.Start("Consultando papel en " + config.Load().SSH().Name())
+			m.remoteRunning = true
+			m.viewController.Set(RemoteView)
+			return m, m.fetchQuota(config.Load().Account)
 		case "Configuración de Impresión":
 			m.viewController.Set(PrinterView)
 		case "Configurar Cuenta":
 			m.viewController.Set(AccountView)
 			m.accountManager.AccountInput.Focus()
+		case "Configurar Conexión":
+			m.SSHView.SetSettings(config.Load().SSH())
+			m.viewController.Set(SSHView)
+		case "Configurar Llave SSH":
+			m.openKeySetup(false)
 		case "Cambiar Theme":
 			m.themeMenu.Reset()
 			m.viewController.Set(ThemeView)
@@ -177,47 +319,90 @@ func (m *Model) updateMainView(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) updatePrintView(msg tea.Msg) (tea.Model, tea.Cmd) {
-	newSelector, selectorCmd := m.PrintView.Update(msg)
-	m.PrintView = newSelector.(components.PrintView)
-
 	// Meanwhile printCompleted is active, just the view is shown
 	if m.printCompleted {
-		if key, ok := msg.(tea.KeyMsg); ok && (key.String() == "enter" || key.String() == "q" || key.String() == "ctrl+c") {
-			return m, tea.Quit
+		if key, ok := msg.(tea.KeyMsg); ok && !m.PrintView.Busy() {
+			return m.updateScriptReady(key)
+		}
+		if _, ok := msg.(spinner.TickMsg); ok {
+			newSelector, selectorCmd := m.PrintView.Update(msg)
+			m.PrintView = newSelector.(components.PrintView)
+			return m, selectorCmd
 		}
 		return m, nil
 	}
 
-	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
-		filename := m.PrintView.SelectedItem()
-		scriptName, err := scripts.CreateScript(filename)
-		if err != nil {
-			log.Fatalf("Error creando script: %v\n", err)
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "c" && m.validateCancel != nil && !m.PrintView.AskingPages() {
+		m.stopValidation()
+		return m, nil
+	}
+
+	dir := m.PrintView.Dir()
+	newSelector, selectorCmd := m.PrintView.Update(msg)
+	m.PrintView = newSelector.(components.PrintView)
+
+	if !m.PrintView.Confirmed() {
+		if m.PrintView.Dir() != dir {
+			return m, tea.Batch(selectorCmd, m.startValidation())
+		}
+		return m, selectorCmd
+	}
+
+	cfg := config.Load()
+	m.rememberDir(m.PrintView.Dir())
+	m.stopValidation()
+
+	if m.PrintView.Action() == components.ActionSend {
+		var jobs []scripts.Job
+		for _, filename := range m.PrintView.SelectedItems() {
+			job := scripts.NewJob(filename, cfg)
+			job.Pages = m.PrintView.Pages()
+			job.Collapse = m.PrintView.Overlays()
+			job.Copies, job.Collate = m.PrintView.Copies()
+			jobs = append(jobs, job)
 		}
-		command := fmt.Sprintf("./%s", scriptName)
-		if err := scripts.CopyToClipboard(command); err != nil {
-			m.PrintView.StatusMessage = fmt.Sprintf("Error copiando al clipboard: %v", err)
-		} else {
-			m.PrintView.StatusMessage = "Script generado exitosamente!\n" +
-				"Nombre del script generado: " + scriptName + "\n" +
-				"Comando copiado al clipboard: " + command + "\n" +
-				"\nSiguientes pasos:\n" +
-				"> Ctrl+Shift+V + Enter para ejecutar el script\n" +
-				"> Ingresa tu contraseña SSH cuando se solicite\n" +
-				"\nPresiona Enter, q o Ctrl+C para salir."
+		if m.PrintView.DryRun() {
+			return m, m.startDryRun(jobs, true)
 		}
-		m.printCompleted = true
-		return m, nil
+		m.PrintView.ClearMarks()
+		m.PrintView.Reset()
+		return m, m.startBatch(jobs)
 	}
 
-	return m, selectorCmd
+	filename := m.PrintView.SelectedItem()
+	job := scripts.NewJob(filename, cfg)
+	job.Pages = m.PrintView.Pages()
+	job.Collapse = m.PrintView.Overlays()
+	job.Copies, job.Collate = m.PrintView.Copies()
+	if m.PrintView.DryRun() {
+		return m, m.startDryRun([]scripts.Job{job}, false)
+	}
+
+	// The old flow: a script to paste and run by hand
+	m.printCompleted = true
+	busy := m.PrintView.SetBusy("Validando y generando el script de " + filepath.Base(filename) + "...")
+	return m, tea.Batch(busy, createScript(job))
 }
+
+// rememberDir stores dir as the most recent directory printed from
+func (m *Model) rememberDir(dir string) {
+	if err := config.SaveRecentDir(dir); err == nil {
+		m.config.RecentDirs = config.Load().RecentDirs
+		m.PrintView.SetRecentDirs(m.config.RecentDirs)
+	}
+}
+
 func (m *Model) updatePrinterView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	newMenu, menuCmd := m.PrinterView.Menu.Update(msg)
 	m.PrinterView.Menu = newMenu.(components.Menu)
 	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
 		selectedPrinter := m.PrinterView.Menu.SelectedItem()
-		config.SavePrinter(selectedPrinter)
+		if err := config.SavePrinter(selectedPrinter); err != nil {
+			m.showError(err)
+			return m, nil
+		}
+		m.ModeView = newModeView(m.theme, selectedPrinter)
+		m.ModeView.SetSize(m.width, m.height)
 		m.viewController.Set(ModeView)
 	}
 	return m, menuCmd
@@ -228,21 +413,55 @@ func (m *Model) updateModeView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.ModeView.Menu = newMenu.(components.Menu)
 	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
 		selectedMode := m.ModeView.Menu.SelectedItem()
-		config.SaveMode(selectedMode)
-		m.viewController.Set(MainView)
+		if err := config.SaveMode(selectedMode); err != nil {
+			m.showError(err)
+			return m, nil
+		}
+		cfg := config.Load()
+		m.LayoutView.SetLayout(cfg.NUp, cfg.NUpBorder)
+		m.LayoutView.Signature = cfg.Signature
+		m.viewController.Set(LayoutView)
 	}
 	return m, menuCmd
 }
 
+func (m *Model) updateLayoutView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	newView, cmd := m.LayoutView.Update(msg)
+	m.LayoutView = newView.(components.LayoutView)
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
+		if err := config.SaveLayout(m.LayoutView.NUp(), m.LayoutView.Border); err != nil {
+			m.showError(err)
+			return m, nil
+		}
+		if err := config.SaveSignature(m.LayoutView.Signature); err != nil {
+			m.showError(err)
+			return m, nil
+		}
+		m.viewController.Set(MainView)
+	}
+	return m, cmd
+}
+
 func (m *Model) updateThemeView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	newMenu, menuCmd := m.themeMenu.Update(msg)
 	m.themeMenu = newMenu.(components.Menu)
 	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
 		selectedTheme := m.themeMenu.SelectedItem()
-		m.themeManager.ChangeTheme(selectedTheme)
+		if err := m.themeManager.ChangeTheme(selectedTheme); err != nil {
+			m.showError(err)
+			return m, nil
+		}
 		m.theme = theme.New(m.themeManager.Current)
 		m.mainMenu.SetTheme(m.theme)
 		m.themeMenu.SetTheme(m.theme)
+		m.RemoteView.SetTheme(m.theme)
+		m.PrintView.SetTheme(m.theme)
+		m.QueueView.SetTheme(m.theme)
+		m.HistoryView.SetTheme(m.theme)
+		m.ErrorView.SetTheme(m.theme)
+		m.LayoutView.SetTheme(m.theme)
+		m.SSHView.SetTheme(m.theme)
+		m.keyManager.SetTheme(m.theme)
 		m.accountManager.AccountInput.PromptStyle = lipgloss.NewStyle().Foreground(m.theme.Selected)
 		m.accountManager.AccountInput.TextStyle = lipgloss.NewStyle().Foreground(m.theme.Header)
 		m.mainMenu.Reset()
@@ -253,7 +472,10 @@ func (m *Model) updateThemeView(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) updateAccountView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
-		m.accountManager.SaveAccount()
+		if err := m.accountManager.SaveAccount(); err != nil {
+			m.showError(err)
+			return m, nil
+		}
 		m.mainMenu.Reset()
 		m.viewController.Set(MainView)
 		return m, nil
@@ -264,6 +486,25 @@ func (m *Model) updateAccountView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 }
 
+func (m *Model) updateSSHView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
+		settings, err := m.SSHView.Settings()
+		if err == nil {
+			err = config.SaveSSH(settings)
+		}
+		if err != nil {
+			m.showError(err)
+			return m, nil
+		}
+		m.mainMenu.Reset()
+		m.viewController.Set(MainView)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.SSHView, cmd = m.SSHView.Update(msg)
+	return m, cmd
+}
+
 // --- Main View ---
 func (m *Model) View() string {
 	header := components.RenderHeader(m.width, m.theme)
@@ -278,12 +519,26 @@ func (m *Model) View() string {
 		view = m.viewPrinter()
 	case ModeView:
 		view = m.viewMode()
+	case LayoutView:
+		view = m.viewLayout()
 	case AccountView:
 		view = m.viewAccount()
+	case SSHView:
+		view = m.SSHView.View()
+	case KeyView:
+		view = m.keyManager.View()
 	case ThemeView:
 		view = m.viewTheme()
 	case FreshView:
 		view = m.viewFreshView()
+	case RemoteView:
+		view = m.viewRemote()
+	case QueueView:
+		view = m.viewQueue()
+	case HistoryView:
+		view = m.viewHistory()
+	case ErrorView:
+		view = m.viewError()
 	}
 	content := lipgloss.JoinVertical(lipgloss.Left, header, view)
 	centeredContent := lipgloss.NewStyle().Width(m.width).Align(lipgloss.Center).Render(content)
@@ -299,7 +554,11 @@ func (m *Model) View() string {
 
 // --- View helpers ---
 func (m *Model) viewMain() string {
-	return m.mainMenu.View()
+	if m.config.Quota == nil {
+		return m.mainMenu.View()
+	}
+	quotaStyle := lipgloss.NewStyle().Foreground(m.theme.Unselected)
+	return lipgloss.JoinVertical(lipgloss.Left, m.mainMenu.View(), quotaStyle.Render(m.config.Quota.String()))
 }
 
 func (m *Model) viewPrint() string {
@@ -322,10 +581,17 @@ func (m *Model) viewMode() string {
 	return m.ModeView.View()
 }
 
+func (m *Model) viewLayout() string {
+	return m.LayoutView.View()
+}
+
 func (m *Model) updateFreshView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
-		m.freshManager.SaveAccount()
-		m.viewController.Set(PrinterView)
+		if err := m.freshManager.SaveAccount(); err != nil {
+			m.showError(err)
+			return m, nil
+		}
+		m.openKeySetup(true)
 		return m, nil
 	} else {
 		var inputCmd tea.Cmd
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fgonzalezurriola/dccprint/internal/components/scripts"
+)
+
+// planMsg carries the explanation of the jobs confirmed in simulation mode
+type planMsg struct {
+	jobs  []scripts.Job
+	plans []scripts.Plan
+	send  bool
+	err   error
+}
+
+// explainJobs works out the plans without blocking the UI while pages are counted
+func explainJobs(jobs []scripts.Job, send bool) tea.Cmd {
+	return func() tea.Msg {
+		var plans []scripts.Plan
+		for _, job := range jobs {
+			plan, err := job.Explain()
+			if err != nil {
+				return planMsg{err: err}
+			}
+			plans = append(plans, plan)
+		}
+		return planMsg{jobs: jobs, plans: plans, send: send}
+	}
+}
+
+// startDryRun shows what the confirmed jobs would do instead of running them
+func (m *Model) startDryRun(jobs []scripts.Job, send bool) tea.Cmd {
+	m.printCompleted = true
+	name := filepath.Base(jobs[0].File)
+	if len(jobs) > 1 {
+		name = fmt.Sprintf("%d archivos", len(jobs))
+	}
+	busy := m.PrintView.SetBusy("Simulando la impresión de " + name + "...")
+	return tea.Batch(busy, explainJobs(jobs, send))
+}
+
+func (m *Model) updatePlanMsg(msg planMsg) (tea.Model, tea.Cmd) {
+	m.printCompleted = false
+	m.PrintView.SetBusy("")
+	m.PrintView.ClearMarks()
+	m.PrintView.Reset()
+	if msg.err != nil {
+		m.showError(msg.err)
+		return m, nil
+	}
+
+	var b strings.Builder
+	sheets := 0
+	for _, plan := range msg.plans {
+		b.WriteString(plan.String() + "\n")
+		sheets += plan.Sheets
+	}
+	if len(msg.plans) > 1 {
+		fmt.Fprintf(&b, "Total: %d hojas\n", sheets)
+	}
+	if m.config.Quota != nil {
+		fmt.Fprintf(&b, "Te quedan %d hojas según la última consulta.\n", m.config.Quota.Remaining)
+	}
+
+	m.RemoteView.Start("Simulación: no se imprimió nada")
+	m.remoteRunning = false
+	// Enter in the remote view sends the pending jobs for real
+	if msg.send {
+		m.pendingJobs = msg.jobs
+		b.WriteString("\nPresiona Enter para imprimir o Esc para volver al menú.")
+	} else {
+		b.WriteString("\nPresiona Enter para volver al menú.")
+	}
+	m.RemoteView.StatusMessage = b.String()
+	m.viewController.Set(RemoteView)
+	return m, nil
+}
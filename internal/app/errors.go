@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fgonzalezurriola/dccprint/internal/components/scripts"
+	"github.com/fgonzalezurriola/dccprint/internal/config"
+)
+
+const genericTitle = "Ocurrió un error"
+
+// showError replaces the current view with an explanation of err
+func (m *Model) showError(err error) {
+	m.ErrorView.Title, m.ErrorView.Explanation, m.ErrorView.Fix = describeError(err)
+	m.ErrorView.Detail = err.Error()
+	m.viewController.Set(ErrorView)
+}
+
+// describeError turns the errors of the scripts and config packages
+// into a title, an explanation and a suggested fix
+func describeError(err error) (title, explanation, fix string) {
+	var invalid *scripts.InvalidPDFError
+	var dirErr *scripts.DirError
+	var writeErr *config.WriteError
+
+	switch {
+	case errors.As(err, &invalid):
+		return "PDF inválido",
+			filepath.Base(invalid.File) + " está dañado o no es un PDF, la impresora no lo podría imprimir.",
+			"Vuelve a descargarlo o expórtalo otra vez a PDF desde el programa que lo generó."
+	case errors.As(err, &dirErr):
+		fix := "Revisa los permisos de la carpeta o abre dccprint en otra con dccprint <carpeta>."
+		if errors.Is(err, os.ErrNotExist) {
+			fix = "La carpeta ya no existe. Abre dccprint desde otra con dccprint <carpeta>."
+		}
+		return "No se pudo leer la carpeta",
+			"dccprint no puede listar los archivos de " + dirErr.Dir + ".",
+			fix
+	case errors.As(err, &writeErr):
+		return "No se pudo guardar la configuración",
+			"Los cambios no se guardaron en " + writeErr.Path + " y se perderán al cerrar dccprint.",
+			"Revisa que tengas permisos de escritura en tu home y espacio libre en el disco."
+	}
+	return genericTitle, "La acción no se pudo completar.", "Vuelve al menú e inténtalo de nuevo."
+}
+
+// explained reports whether describeError knows what err means
+func explained(err error) bool {
+	if err == nil {
+		return false
+	}
+	title, _, _ := describeError(err)
+	return title != genericTitle
+}
+
+func (m *Model) updateErrorView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
+		m.mainMenu.Reset()
+		m.viewController.Set(MainView)
+	}
+	return m, nil
+}
+
+func (m *Model) viewError() string {
+	return m.ErrorView.View()
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fgonzalezurriola/dccprint/internal/components/scripts"
+	"github.com/fgonzalezurriola/dccprint/internal/history"
+)
+
+// openHistory shows the jobs printed from this computer
+func (m *Model) openHistory() {
+	entries, err := history.Load()
+	m.HistoryView.SetEntries(entries)
+	m.HistoryView.StatusMessage = ""
+	if err != nil {
+		m.HistoryView.StatusMessage = "Error leyendo el historial: " + err.Error()
+	}
+	m.viewController.Set(HistoryView)
+}
+
+func (m *Model) updateHistoryView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
+		entry, ok := m.HistoryView.SelectedEntry()
+		if !ok {
+			return m, nil
+		}
+		job, err := scripts.JobFromEntry(entry)
+		if err != nil {
+			m.HistoryView.StatusMessage = "Error: " + err.Error()
+			return m, nil
+		}
+		if _, err := os.Stat(job.File); err != nil {
+			m.HistoryView.StatusMessage = "El archivo ya no existe: " + job.File
+			return m, nil
+		}
+		cmd := m.startBatch([]scripts.Job{job})
+		if entry.Changed() {
+			m.RemoteView.AppendLine("Aviso: el archivo cambió desde que se imprimió")
+		}
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.HistoryView, cmd = m.HistoryView.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) viewHistory() string {
+	return m.HistoryView.View()
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fgonzalezurriola/dccprint/internal/config"
+	"github.com/fgonzalezurriola/dccprint/internal/remote"
+)
+
+// keySetupMsg ends the setup of the SSH key
+type keySetupMsg struct {
+	err error
+	// agentKey is set to the key when it works but has a passphrase that
+	// would be asked on every print, until it is added to ssh-agent
+	agentKey string
+}
+
+// openKeySetup shows the SSH key step, after the account when onboarding
+func (m *Model) openKeySetup(onboarding bool) {
+	m.onboarding = onboarding
+	m.keyManager.Check(config.Load().SSH())
+	m.viewController.Set(KeyView)
+}
+
+// finishKeySetup moves on to the printer when onboarding, or back to the menu
+func (m *Model) finishKeySetup() {
+	if m.onboarding {
+		m.onboarding = false
+		m.viewController.Set(PrinterView)
+		return
+	}
+	m.mainMenu.Reset()
+	m.viewController.Set(MainView)
+}
+
+func (m *Model) updateKeyView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "enter":
+			return m, m.startKeySetup()
+		case "n":
+			m.finishKeySetup()
+		}
+	}
+	return m, nil
+}
+
+// startKeySetup generates the key when there is none, authorizes it in
+// anakena with the password and logs in again with the key alone
+func (m *Model) startKeySetup() tea.Cmd {
+	cfg := config.Load()
+	conn, settings, account := m.conn, cfg.SSH(), cfg.Account
+	path, exists := m.keyManager.Key, m.keyManager.Exists
+	events := m.events
+
+	m.RemoteView.Start("Configurando llave SSH para " + settings.Name())
+	m.remoteRunning = true
+	m.viewController.Set(RemoteView)
+
+	return func() tea.Msg {
+		out := func(line string) { events <- remoteOutputMsg(line) }
+		comment := "dccprint"
+		if host, err := os.Hostname(); err == nil {
+			comment += "@" + host
+		}
+
+		if !exists {
+			out("Generando una llave ed25519 en " + path)
+			if err := remote.GenerateKey(path, comment); err != nil {
+				return keySetupMsg{err: err}
+			}
+		}
+		signer, err := remote.LoadKey(path, chanPrompter{events: events})
+		if err != nil {
+			return keySetupMsg{err: err}
+		}
+
+		out("Conectando a " + settings.Name())
+		client, err := conn.Client(settings, account)
+		if err != nil {
+			return keySetupMsg{err: err}
+		}
+		if client.CheckKey(signer) == nil {
+			out("La llave ya estaba autorizada")
+		} else {
+			out("Agregando la llave a ~/.ssh/authorized_keys")
+			if err := client.InstallKey(signer.PublicKey(), comment); err != nil {
+				return keySetupMsg{err: err}
+			}
+			out("Probando el ingreso con la llave")
+			if err := client.CheckKey(signer); err != nil {
+				return keySetupMsg{err: err}
+			}
+		}
+		if remote.KeyEncrypted(path) && !remote.InAgent(signer.PublicKey()) {
+			return keySetupMsg{agentKey: path}
+		}
+		return keySetupMsg{}
+	}
+}
+
+func (m *Model) updateKeySetupMsg(msg keySetupMsg) (tea.Model, tea.Cmd) {
+	m.remoteRunning = false
+	if explained(msg.err) {
+		m.onboarding = false
+		m.showError(msg.err)
+		return m, nil
+	}
+	switch {
+	case msg.err != nil:
+		m.RemoteView.StatusMessage = "Error: " + msg.err.Error() + "\n\nPresiona Enter para continuar."
+	case msg.agentKey != "":
+		m.RemoteView.StatusMessage = "La llave quedó autorizada, pero tiene frase y se pediría en cada impresión.\n" +
+			"Agrégala a ssh-agent con: ssh-add " + msg.agentKey + "\n\nPresiona Enter para continuar."
+	default:
+		m.RemoteView.StatusMessage = "¡Listo! La llave funciona, ya no se pedirá tu contraseña DCC.\n\nPresiona Enter para continuar."
+	}
+	return m, nil
+}
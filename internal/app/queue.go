@@ -0,0 +1,150 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fgonzalezurriola/dccprint/internal/config"
+	"github.com/fgonzalezurriola/dccprint/internal/queue"
+)
+
+// How often the queue view asks lpq again
+const queueRefreshInterval = 10 * time.Second
+
+type queueMsg struct {
+	queues []queue.Queue
+	// account is the user lpq ran as, the owner of the jobs that can be cancelled
+	account string
+	err     error
+}
+
+type queueCancelMsg struct {
+	id  int
+	err error
+}
+
+type queueTickMsg struct{}
+
+// fetchQueues runs lpq for every printer over the shared connection, as the
+// account saved now, it may have changed since the app started
+func (m *Model) fetchQueues() tea.Cmd {
+	cfg := config.Load()
+	conn, settings, account := m.conn, cfg.SSH(), cfg.Account
+	return func() tea.Msg {
+		client, err := conn.Client(settings, account)
+		if err != nil {
+			return queueMsg{err: err}
+		}
+
+		var queues []queue.Queue
+		for _, printer := range config.LoadRegistry().Printers {
+			out, err := client.Output(printer.ListCommand())
+			if err != nil {
+				return queueMsg{err: fmt.Errorf("falló lpq de %s: %w", printer.Name, err)}
+			}
+			q := queue.Parse(out)
+			q.Printer = printer.Name
+			queues = append(queues, q)
+		}
+		return queueMsg{queues: queues, account: account}
+	}
+}
+
+// cancelJob removes one of the user's jobs with lprm
+func (m *Model) cancelJob(printerName string, id int) tea.Cmd {
+	cfg := config.Load()
+	conn, settings, account := m.conn, cfg.SSH(), cfg.Account
+	return func() tea.Msg {
+		printer, err := config.LoadRegistry().Printer(printerName)
+		if err != nil {
+			return queueCancelMsg{id: id, err: err}
+		}
+		client, err := conn.Client(settings, account)
+		if err != nil {
+			return queueCancelMsg{id: id, err: err}
+		}
+		if out, err := client.Output(printer.CancelCommand(id)); err != nil {
+			return queueCancelMsg{id: id, err: fmt.Errorf("%w: %s", err, out)}
+		}
+		return queueCancelMsg{id: id}
+	}
+}
+
+func queueTick() tea.Cmd {
+	return tea.Tick(queueRefreshInterval, func(time.Time) tea.Msg {
+		return queueTickMsg{}
+	})
+}
+
+// openQueue shows the queue view and starts the first fetch
+func (m *Model) openQueue() tea.Cmd {
+	m.QueueView.Reset()
+	m.QueueView.Loading = true
+	m.viewController.Set(QueueView)
+	return m.fetchQueues()
+}
+
+// updateQueueMsg handles the results of lpq, lprm and the refresh timer
+func (m *Model) updateQueueMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case queueMsg:
+		m.QueueView.Loading = false
+		if msg.err != nil {
+			m.QueueView.StatusMessage = "Error: " + msg.err.Error()
+		} else {
+			m.QueueView.SetQueues(msg.queues, msg.account)
+		}
+		if m.viewController.Get() == QueueView {
+			return m, queueTick()
+		}
+	case queueCancelMsg:
+		if msg.err != nil {
+			m.QueueView.StatusMessage = fmt.Sprintf("No se pudo cancelar el trabajo %d: %v", msg.id, msg.err)
+		} else {
+			m.QueueView.StatusMessage = fmt.Sprintf("Trabajo %d cancelado", msg.id)
+		}
+		m.QueueView.Loading = true
+		return m, m.fetchQueues()
+	case queueTickMsg:
+		// The timer dies when the user leaves the view
+		if m.viewController.Get() == QueueView && !m.QueueView.Loading {
+			m.QueueView.Loading = true
+			return m, m.fetchQueues()
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) updateQueueView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "r":
+			if !m.QueueView.Loading {
+				m.QueueView.Loading = true
+				return m, m.fetchQueues()
+			}
+			return m, nil
+		case "x":
+			printer, job, ok := m.QueueView.SelectedJob()
+			if !ok {
+				return m, nil
+			}
+			if !m.QueueView.Owned(job) {
+				m.QueueView.StatusMessage = "Solo puedes cancelar tus propios trabajos"
+				return m, nil
+			}
+			m.QueueView.StatusMessage = fmt.Sprintf("Cancelando trabajo %d...", job.ID)
+			return m, m.cancelJob(printer, job.ID)
+		}
+	}
+
+	var cmd tea.Cmd
+	m.QueueView, cmd = m.QueueView.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) viewQueue() string {
+	return m.QueueView.View()
+}
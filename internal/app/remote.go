@@ -0,0 +1,295 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fgonzalezurriola/dccprint/internal/components/scripts"
+	"github.com/fgonzalezurriola/dccprint/internal/config"
+	"github.com/fgonzalezurriola/dccprint/internal/quota"
+	"github.com/fgonzalezurriola/dccprint/internal/remote"
+)
+
+// Messages sent from the SSH goroutines back into the Update loop
+type remotePromptMsg struct {
+	question string
+	echo     bool
+	answer   chan string
+}
+
+type remoteOutputMsg string
+
+type remoteDoneMsg struct {
+	err error
+	// submitted are the jobs found in the queue, also sent one by one through
+	// the events channel, which may be read after this message
+	submitted []submittedMsg
+}
+
+type batchStatusMsg struct {
+	index  int
+	status scripts.JobStatus
+}
+
+// quotaCheckMsg carries the warning for jobs about to be sent, empty when
+// the cached quota is enough. gen drops the checks of batches cancelled.
+type quotaCheckMsg struct {
+	gen     int
+	jobs    []scripts.Job
+	warning string
+}
+
+type quotaMsg struct {
+	quota quota.Quota
+	err   error
+}
+
+// chanPrompter forwards the server's password prompts to the TUI
+// and blocks until the user answers. A closed answer channel means cancel.
+type chanPrompter struct {
+	events chan<- tea.Msg
+}
+
+func (p chanPrompter) Prompt(question string, echo bool) (string, error) {
+	answer := make(chan string, 1)
+	p.events <- remotePromptMsg{question: question, echo: echo, answer: answer}
+	value, ok := <-answer
+	if !ok {
+		return "", remote.ErrCancelled
+	}
+	return value, nil
+}
+
+// waitForEvent delivers the next message posted by a background goroutine
+func waitForEvent(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
+
+// sendBatch prints jobs one after the other through the shared SSH connection,
+// streaming their output and reporting the status of every file
+func (m *Model) sendBatch(jobs []scripts.Job) tea.Cmd {
+	conn, settings := m.conn, config.Load().SSH()
+	events := m.events
+	// The jobs of an earlier batch stop being followed
+	m.tracking = nil
+	m.trackGen++
+	m.trackErrors = 0
+	if len(jobs) > 0 {
+		m.trackAccount = jobs[0].Account
+	}
+	return func() tea.Msg {
+		if len(jobs) == 0 {
+			return remoteDoneMsg{}
+		}
+		client, err := conn.Client(settings, jobs[0].Account)
+		if err != nil {
+			return remoteDoneMsg{err: err}
+		}
+
+		out := remote.NewLineWriter(func(line string) {
+			events <- remoteOutputMsg(line)
+		})
+		failed := 0
+		var lastErr error
+		var submitted []submittedMsg
+		for i, job := range jobs {
+			id, err := scripts.SendJob(client, job, out, func(status scripts.JobStatus) {
+				events <- batchStatusMsg{index: i, status: status}
+			})
+			out.Flush()
+			if id > 0 {
+				msg := submittedMsg{index: i, printer: job.Printer, id: id}
+				submitted = append(submitted, msg)
+				events <- msg
+			}
+			if err != nil {
+				failed++
+				lastErr = err
+				events <- remoteOutputMsg(filepath.Base(job.File) + ": " + err.Error())
+			}
+		}
+
+		if failed == 1 && len(jobs) == 1 {
+			return remoteDoneMsg{err: lastErr, submitted: submitted}
+		}
+		if failed > 0 {
+			return remoteDoneMsg{err: fmt.Errorf("%d de %d archivos fallaron", failed, len(jobs)), submitted: submitted}
+		}
+		return remoteDoneMsg{submitted: submitted}
+	}
+}
+
+// startBatch shows the progress view and sends jobs, asking first
+// when they need more paper than the cached quota
+func (m *Model) startBatch(jobs []scripts.Job) tea.Cmd {
+	names := make([]string, len(jobs))
+	for i, job := range jobs {
+		names[i] = filepath.Base(job.File)
+	}
+
+	title := "Imprimiendo " + names[0] + " en " + jobs[0].Printer
+	if len(jobs) > 1 {
+		title = fmt.Sprintf("Imprimiendo %d archivos en %s", len(jobs), jobs[0].Printer)
+	}
+	m.RemoteView.Start(title)
+	m.RemoteView.SetItems(names, scripts.StatusPending.String())
+	m.viewController.Set(RemoteView)
+
+	// Estimating the paper converts and imposes the files, off the Update loop
+	m.batchGen++
+	m.remoteRunning = true
+	gen, cached := m.batchGen, m.config.Quota
+	return func() tea.Msg {
+		return quotaCheckMsg{gen: gen, jobs: jobs, warning: quotaWarning(jobs, cached)}
+	}
+}
+
+// fetchQuota runs papel in anakena and parses the remaining sheets
+func (m *Model) fetchQuota(account string) tea.Cmd {
+	conn, settings := m.conn, config.Load().SSH()
+	return func() tea.Msg {
+		client, err := conn.Client(settings, account)
+		if err != nil {
+			return quotaMsg{err: err}
+		}
+		out, err := client.Output(quota.Command)
+		if err != nil {
+			return quotaMsg{err: fmt.Errorf("falló %s: %w", quota.Command, err)}
+		}
+		q, err := quota.Parse(out)
+		return quotaMsg{quota: q, err: err}
+	}
+}
+
+// quotaWarning returns a message when the jobs need more paper than the cached quota
+func quotaWarning(jobs []scripts.Job, cached *quota.Quota) string {
+	if cached == nil {
+		return ""
+	}
+	sheets := 0
+	for _, job := range jobs {
+		n, err := job.EstimateSheets()
+		if err != nil {
+			continue
+		}
+		sheets += n
+	}
+	if sheets <= cached.Remaining {
+		return ""
+	}
+	return fmt.Sprintf("Advertencia: este trabajo usa %d hojas y te quedan %d.\n", sheets, cached.Remaining) +
+		"\nPresiona Enter para imprimir igual o Esc para cancelar."
+}
+
+// updateRemoteMsg handles the messages coming from the SSH goroutines
+func (m *Model) updateRemoteMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case remotePromptMsg:
+		m.pendingAnswer = msg.answer
+		// Go back to whatever asked for the connection once the password is in
+		m.promptReturn = m.viewController.Get()
+		if m.promptReturn != RemoteView {
+			m.RemoteView.Start("Conectando a " + config.Load().SSH().Name())
+		}
+		m.viewController.Set(RemoteView)
+		return m, tea.Batch(m.RemoteView.Ask(msg.question, msg.echo), waitForEvent(m.events))
+	case remoteOutputMsg:
+		m.RemoteView.AppendLine(string(msg))
+		return m, waitForEvent(m.events)
+	case batchStatusMsg:
+		m.RemoteView.SetItemStatus(msg.index, msg.status.String())
+		return m, waitForEvent(m.events)
+	case remoteDoneMsg:
+		m.remoteRunning = false
+		for _, job := range msg.submitted {
+			m.track(job)
+		}
+		if explained(msg.err) {
+			m.showError(msg.err)
+			return m, nil
+		}
+		if msg.err != nil {
+			m.RemoteView.StatusMessage = "Error: " + msg.err.Error() + "\n\nPresiona Enter para volver al menú."
+		} else {
+			m.RemoteView.StatusMessage = "¡Impresión enviada!\n" +
+				"Nota: El comando papel se actualiza después de haber finalizado la impresión\n" +
+				"\nPresiona Enter para volver al menú."
+		}
+		if len(m.tracking) > 0 {
+			m.RemoteView.StatusMessage = "Se avisará con un sonido cuando termine de imprimirse.\n" + m.RemoteView.StatusMessage
+			return m, trackTick(m.trackGen)
+		}
+	case quotaCheckMsg:
+		if msg.gen != m.batchGen {
+			return m, nil
+		}
+		if msg.warning != "" {
+			m.remoteRunning = false
+			m.pendingJobs = msg.jobs
+			m.RemoteView.StatusMessage = msg.warning
+			return m, nil
+		}
+		return m, m.sendBatch(msg.jobs)
+	case quotaMsg:
+		if msg.err != nil {
+			m.RemoteView.StatusMessage = "Error: " + msg.err.Error() + "\n\nPresiona Enter para volver al menú."
+		} else {
+			m.config.Quota = &msg.quota
+			if err := config.SaveQuota(msg.quota); err != nil {
+				m.RemoteView.AppendLine("No se pudo guardar el papel consultado: " + err.Error())
+			}
+			m.RemoteView.StatusMessage = msg.quota.String() + "\n\nPresiona Enter para volver al menú."
+		}
+		m.remoteRunning = false
+	}
+	return m, nil
+}
+
+func (m *Model) updateRemoteView(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
+		if m.RemoteView.Prompting() {
+			m.pendingAnswer <- m.RemoteView.Answer()
+			m.pendingAnswer = nil
+			m.viewController.Set(m.promptReturn)
+			return m, nil
+		}
+		if m.pendingJobs != nil {
+			jobs := m.pendingJobs
+			m.pendingJobs = nil
+			m.RemoteView.StatusMessage = ""
+			m.remoteRunning = true
+			return m, m.sendBatch(jobs)
+		}
+		if !m.remoteRunning && m.onboarding {
+			m.finishKeySetup()
+			return m, nil
+		}
+		if !m.remoteRunning {
+			m.PrintView.Reset()
+			m.mainMenu.Reset()
+			m.viewController.Set(MainView)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.RemoteView, cmd = m.RemoteView.Update(msg)
+	return m, cmd
+}
+
+// cancelPrompt dismisses a pending password prompt, making the dial fail
+func (m *Model) cancelPrompt() {
+	if m.pendingAnswer != nil {
+		m.RemoteView.Answer()
+		close(m.pendingAnswer)
+		m.pendingAnswer = nil
+	}
+}
+
+func (m *Model) viewRemote() string {
+	return m.RemoteView.View()
+}
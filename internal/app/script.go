@@ -0,0 +1,126 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fgonzalezurriola/dccprint/internal/clipboard"
+	"github.com/fgonzalezurriola/dccprint/internal/components/scripts"
+)
+
+type scriptMsg struct {
+	job  scripts.Job
+	name string
+	err  error
+}
+
+// scriptDoneMsg arrives when the script run with "Ejecutar ahora" exits
+type scriptDoneMsg struct {
+	name string
+	err  error
+}
+
+// createScript writes the job's script without blocking the UI while gs validates
+func createScript(job scripts.Job) tea.Cmd {
+	return func() tea.Msg {
+		name, err := scripts.CreateJobScript(job)
+		return scriptMsg{job: job, name: name, err: err}
+	}
+}
+
+func (m *Model) updateScriptMsg(msg scriptMsg) (tea.Model, tea.Cmd) {
+	m.PrintView.SetBusy("")
+	if msg.err != nil {
+		m.printCompleted = false
+		m.PrintView.Reset()
+		m.showError(msg.err)
+		return m, nil
+	}
+	m.scriptJob = msg.job
+	m.scriptName = msg.name
+	m.PrintView.StatusMessage = "Script generado exitosamente!\n" +
+		"Nombre del script generado: " + msg.name + "\n" +
+		"\nEnter o e: ejecutar ahora (pedirá tu contraseña SSH)\n" +
+		"c: copiar el comando al clipboard para ejecutarlo después\n" +
+		"q o Ctrl+C: salir"
+	return m, nil
+}
+
+// updateScriptReady handles the keys once the script is written
+func (m *Model) updateScriptReady(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key.String() {
+	case "enter", "e":
+		return m, m.runScript()
+	case "c":
+		command := fmt.Sprintf("./%s", m.scriptName)
+		backend := scripts.ClipboardBackend()
+		if err := backend.Copy(command); err != nil {
+			m.PrintView.StatusMessage = fmt.Sprintf("Error copiando al clipboard: %v\n", err) +
+				"\nEjecuta " + command + " en tu terminal o presiona Enter para ejecutarlo ahora."
+		} else if backend.Name() == clipboard.Print {
+			m.PrintView.StatusMessage = "Presiona q para salir y ejecuta en tu terminal:\n" +
+				"\n  " + command + "\n" +
+				"\nO presiona Enter para ejecutarlo ahora."
+		} else {
+			m.PrintView.StatusMessage = "Comando copiado al clipboard: " + command + "\n" +
+				"\nSiguientes pasos:\n" +
+				"> Presiona q para salir\n" +
+				"> Ctrl+Shift+V + Enter para ejecutar el script\n" +
+				"> Ingresa tu contraseña SSH cuando se solicite\n" +
+				"\nO presiona Enter para ejecutarlo ahora."
+		}
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// runScript hands the terminal to the generated script until it exits
+func (m *Model) runScript() tea.Cmd {
+	name := m.scriptName
+	path, err := filepath.Abs(name)
+	if err != nil {
+		path = name
+	}
+	cmd := exec.Command("bash", path)
+	cmd.Env = append(os.Environ(), "DCCPRINT_TUI=1")
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return scriptDoneMsg{name: name, err: err}
+	})
+}
+
+// updateScriptDone shows how the script ended and records it in the history
+func (m *Model) updateScriptDone(msg scriptDoneMsg) (tea.Model, tea.Cmd) {
+	m.printCompleted = false
+	m.PrintView.Reset()
+	// Recording counts the pages, which may convert the file again
+	job := m.scriptJob
+	record := func() tea.Msg {
+		job.Record(msg.err)
+		return nil
+	}
+
+	m.RemoteView.Start("Resultado de " + msg.name)
+	m.remoteRunning = false
+	var exitErr *exec.ExitError
+	switch {
+	case msg.err == nil:
+		m.RemoteView.StatusMessage = "¡Impresión enviada!\n" +
+			"Nota: El comando papel se actualiza después de haber finalizado la impresión\n" +
+			"\nPresiona Enter para volver al menú."
+	case errors.As(msg.err, &exitErr):
+		m.RemoteView.StatusMessage = fmt.Sprintf("El script terminó con error (código %d).\n", exitErr.ExitCode()) +
+			"Revisa tu contraseña y tu conexión a internet, y vuelve a intentarlo.\n" +
+			"\nPresiona Enter para volver al menú."
+	default:
+		m.RemoteView.StatusMessage = "No se pudo ejecutar el script: " + msg.err.Error() + "\n" +
+			"\nPresiona Enter para volver al menú."
+	}
+	m.viewController.Set(RemoteView)
+	return m, record
+}
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fgonzalezurriola/dccprint/internal/config"
+	"github.com/fgonzalezurriola/dccprint/internal/queue"
+)
+
+// How often the queue is asked about the jobs just sent
+const trackInterval = 5 * time.Second
+
+// maxTrackErrors is how many polls in a row can fail before giving up
+const maxTrackErrors = 3
+
+// errTrackClosed stops the tracking when the connection of the batch is gone,
+// dialing again could ask for the password over whatever view is open
+var errTrackClosed = errors.New("se cerró la conexión a anakena")
+
+// trackedJob is a file of the batch found in the printer queue after lpr
+type trackedJob struct {
+	index   int
+	printer string
+	id      int
+	state   queue.State
+}
+
+// submittedMsg tells the id lpq gave to a file of the batch
+type submittedMsg struct {
+	index   int
+	printer string
+	id      int
+}
+
+// trackMsg carries the queues of the printers with tracked jobs
+type trackMsg struct {
+	gen    int
+	queues map[string]queue.Queue
+	err    error
+}
+
+type trackTickMsg struct {
+	gen int
+}
+
+// trackTick waits before asking the queue again, for the batch gen
+func trackTick(gen int) tea.Cmd {
+	return tea.Tick(trackInterval, func(time.Time) tea.Msg {
+		return trackTickMsg{gen: gen}
+	})
+}
+
+// fetchTracked runs lpq on the printers that still hold a tracked job, over
+// the connection the batch was sent with
+func (m *Model) fetchTracked() tea.Cmd {
+	conn, settings, account, gen := m.conn, config.Load().SSH(), m.trackAccount, m.trackGen
+	var printers []string
+	for _, job := range m.tracking {
+		if job.state != queue.StateDone {
+			printers = append(printers, job.printer)
+		}
+	}
+	return func() tea.Msg {
+		client := conn.Open(settings, account)
+		if client == nil {
+			return trackMsg{gen: gen, err: errTrackClosed}
+		}
+		registry := config.LoadRegistry()
+		queues := map[string]queue.Queue{}
+		for _, name := range printers {
+			if _, ok := queues[name]; ok {
+				continue
+			}
+			printer, err := registry.Printer(name)
+			if err != nil {
+				return trackMsg{gen: gen, err: err}
+			}
+			out, err := client.Output(printer.ListCommand())
+			if err != nil {
+				return trackMsg{gen: gen, err: fmt.Errorf("falló lpq de %s: %w", printer.Name, err)}
+			}
+			queues[name] = queue.Parse(out)
+		}
+		return trackMsg{gen: gen, queues: queues}
+	}
+}
+
+// trackLabel is the status shown next to a file while it goes through the queue
+func trackLabel(job trackedJob, ahead int, q queue.Queue) string {
+	switch job.state {
+	case queue.StateQueued:
+		if ahead > 0 {
+			return fmt.Sprintf("en cola, trabajo %d (%d antes)", job.id, ahead)
+		}
+		return fmt.Sprintf("en cola, trabajo %d", job.id)
+	case queue.StateStuck:
+		problem, _ := q.Problem()
+		return fmt.Sprintf("detenido, trabajo %d: %s", job.id, problem)
+	}
+	return fmt.Sprintf("%s, trabajo %d", job.state, job.id)
+}
+
+// bell rings the terminal, written to stderr like the OSC 52 clipboard
+func bell() tea.Msg {
+	fmt.Fprint(os.Stderr, "\a")
+	return nil
+}
+
+// track starts following a submitted job, once even if it is reported
+// both on its own and at the end of the batch
+func (m *Model) track(msg submittedMsg) {
+	for _, job := range m.tracking {
+		if job.index == msg.index {
+			return
+		}
+	}
+	m.tracking = append(m.tracking, trackedJob{index: msg.index, printer: msg.printer, id: msg.id})
+	m.RemoteView.SetItemStatus(msg.index, fmt.Sprintf("en cola, trabajo %d", msg.id))
+}
+
+// updateTrackMsg follows the jobs of the last batch until they leave the queue,
+// ringing the bell as each one finishes
+func (m *Model) updateTrackMsg(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case submittedMsg:
+		m.track(msg)
+		return m, waitForEvent(m.events)
+	case trackTickMsg:
+		if msg.gen == m.trackGen {
+			return m, m.fetchTracked()
+		}
+	case trackMsg:
+		if msg.gen != m.trackGen {
+			return m, nil
+		}
+		// A failed lpq is tried again on the next tick, a few times
+		if msg.err != nil {
+			m.trackErrors++
+			if errors.Is(msg.err, errTrackClosed) || m.trackErrors >= maxTrackErrors {
+				m.tracking = nil
+				m.RemoteView.AppendLine("Se dejó de seguir la cola: " + msg.err.Error())
+				return m, nil
+			}
+			return m, trackTick(m.trackGen)
+		}
+		m.trackErrors = 0
+		var cmds []tea.Cmd
+		pending := false
+		for i, job := range m.tracking {
+			q, ok := msg.queues[job.printer]
+			if job.state == queue.StateDone || !ok {
+				continue
+			}
+			state, ahead := q.Track(job.id)
+			m.tracking[i].state = state
+			m.RemoteView.SetItemStatus(job.index, trackLabel(m.tracking[i], ahead, q))
+			// A stuck printer needs someone at the DCC, it isn't asked again
+			switch state {
+			case queue.StateDone:
+				cmds = append(cmds, bell)
+			case queue.StateStuck:
+			default:
+				pending = true
+			}
+		}
+		if pending {
+			cmds = append(cmds, trackTick(m.trackGen))
+		}
+		return m, tea.Batch(cmds...)
+	}
+	return m, nil
+}
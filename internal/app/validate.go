@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/fgonzalezurriola/dccprint/internal/components"
+	"github.com/fgonzalezurriola/dccprint/internal/components/scripts"
+)
+
+// pdfValidatedMsg is the result of validating one pdf of the listing.
+// gen tells apart the results of a validation already cancelled.
+type pdfValidatedMsg struct {
+	gen  int
+	path string
+	err  error
+	rest []string
+}
+
+// validateNext checks the first of paths, one at a time so gs doesn't hog the cpu
+func validateNext(ctx context.Context, gen int, paths []string) tea.Cmd {
+	return func() tea.Msg {
+		err := scripts.ValidatePDF(ctx, paths[0])
+		return pdfValidatedMsg{gen: gen, path: paths[0], err: err, rest: paths[1:]}
+	}
+}
+
+// startValidation checks in the background the pdfs listed by PrintView
+func (m *Model) startValidation() tea.Cmd {
+	m.stopValidation()
+	paths := m.PrintView.UncheckedPDFs()
+	if len(paths) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.validateGen++
+	m.validateCtx = ctx
+	m.validateCancel = cancel
+	m.validateDone = 0
+	m.validateTotal = len(paths)
+	return tea.Batch(m.PrintView.SetBusy(m.validationStatus()), validateNext(ctx, m.validateGen, paths))
+}
+
+// stopValidation kills the running gs and hides the spinner
+func (m *Model) stopValidation() {
+	if m.validateCancel == nil {
+		return
+	}
+	m.validateCancel()
+	m.validateCancel = nil
+	m.PrintView.SetBusy("")
+}
+
+func (m *Model) validationStatus() string {
+	return fmt.Sprintf("Validando PDFs %d/%d • c: cancelar", m.validateDone+1, m.validateTotal)
+}
+
+// updateValidation records a result and goes on with the next pdf
+func (m *Model) updateValidation(msg pdfValidatedMsg) (tea.Model, tea.Cmd) {
+	if msg.gen != m.validateGen || m.validateCancel == nil || errors.Is(msg.err, context.Canceled) {
+		return m, nil
+	}
+
+	validity := components.Valid
+	if msg.err != nil {
+		validity = components.Broken
+	}
+	m.PrintView.SetValidity(msg.path, validity)
+	m.validateDone++
+
+	if len(msg.rest) == 0 {
+		m.validateCancel()
+		m.validateCancel = nil
+		m.PrintView.SetBusy("")
+		return m, nil
+	}
+	m.PrintView.SetBusy(m.validationStatus())
+	return m, validateNext(m.validateCtx, msg.gen, msg.rest)
+}
@@ -10,7 +10,14 @@ const (
 	AccountView
 	FreshView
 	ThemeView
+	RemoteView
+	QueueView
 	SetupView
+	HistoryView
+	ErrorView
+	LayoutView
+	SSHView
+	KeyView
 )
 
 type ViewController struct {
@@ -0,0 +1,113 @@
+package clipboard
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	native "github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	"golang.org/x/term"
+)
+
+// Names of the backends, as written in the config
+const (
+	Auto   = "auto"
+	Native = "native"
+	OSC52  = "osc52"
+	Print  = "print"
+)
+
+// Names lists the values accepted in the config
+var Names = []string{Auto, Native, OSC52, Print}
+
+// Backend puts text where the user can paste it from
+type Backend interface {
+	Name() string
+	Copy(text string) error
+}
+
+// New returns the backend called name, detecting one from the environment
+// for "auto" or an empty name
+func New(name string) (Backend, error) {
+	switch strings.ToLower(name) {
+	case "", Auto:
+		return Detect(), nil
+	case Native:
+		return nativeBackend{}, nil
+	case OSC52:
+		return newOSC52(), nil
+	case Print:
+		return printBackend{}, nil
+	}
+	return nil, fmt.Errorf("clipboard desconocido: %s (usa %s)", name, strings.Join(Names, ", "))
+}
+
+// Detect picks the backend that works in this session. Over SSH the native
+// tools would copy on the remote machine, so the terminal is asked instead.
+func Detect() Backend {
+	if os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != "" {
+		return newOSC52()
+	}
+	if !native.Unsupported {
+		return nativeBackend{}
+	}
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		return newOSC52()
+	}
+	return printBackend{}
+}
+
+// nativeBackend uses pbcopy, xclip, xsel or wl-copy
+type nativeBackend struct{}
+
+func (nativeBackend) Name() string {
+	return Native
+}
+
+func (nativeBackend) Copy(text string) error {
+	if err := native.WriteAll(text); err != nil {
+		return fmt.Errorf("error copiando al portapapeles: %w", err)
+	}
+	return nil
+}
+
+// osc52Backend asks the terminal to copy with the OSC 52 escape, which also
+// works through SSH. tmux and screen need the sequence wrapped.
+type osc52Backend struct {
+	out io.Writer
+}
+
+func newOSC52() osc52Backend {
+	return osc52Backend{out: os.Stderr}
+}
+
+func (osc52Backend) Name() string {
+	return OSC52
+}
+
+func (b osc52Backend) Copy(text string) error {
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	if _, err := seq.WriteTo(b.out); err != nil {
+		return fmt.Errorf("error copiando con OSC 52: %w", err)
+	}
+	return nil
+}
+
+// printBackend copies nothing, the caller shows the text to copy by hand
+type printBackend struct{}
+
+func (printBackend) Name() string {
+	return Print
+}
+
+func (printBackend) Copy(string) error {
+	return nil
+}
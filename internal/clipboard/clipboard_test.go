@@ -0,0 +1,33 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{Native, OSC52, Print} {
+		b, err := New(name)
+		if err != nil || b.Name() != name {
+			t.Errorf("New(%q) = %v, %v", name, b, err)
+		}
+	}
+	if _, err := New("fax"); err == nil {
+		t.Errorf("New(\"fax\") debería fallar")
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+	var out bytes.Buffer
+	if err := (osc52Backend{out: &out}).Copy("./dccprint-tarea.sh"); err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte("./dccprint-tarea.sh"))
+	if !strings.HasPrefix(out.String(), "\x1b]52;c;"+encoded) {
+		t.Errorf("secuencia OSC 52 = %q", out.String())
+	}
+}
@@ -0,0 +1,56 @@
+package components
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fgonzalezurriola/dccprint/internal/theme"
+)
+
+// ErrorView explains an error and how to fix it
+type ErrorView struct {
+	Title       string
+	Explanation string
+	Fix         string
+	Detail      string
+	theme       *theme.Theme
+	width       int
+	height      int
+}
+
+func NewErrorView(theme *theme.Theme) ErrorView {
+	return ErrorView{theme: theme}
+}
+
+func (e ErrorView) Init() tea.Cmd {
+	return nil
+}
+
+func (e ErrorView) Update(msg tea.Msg) (ErrorView, tea.Cmd) {
+	return e, nil
+}
+
+func (e ErrorView) View() string {
+	titleStyle := lipgloss.NewStyle().Foreground(e.theme.Selected).Bold(true)
+	textStyle := lipgloss.NewStyle()
+	dimStyle := lipgloss.NewStyle().Foreground(e.theme.Unselected)
+	width := max(40, min(e.width-4, 80))
+
+	lines := []string{titleStyle.Render(e.Title), "", textStyle.Width(width).Render(e.Explanation)}
+	if e.Fix != "" {
+		lines = append(lines, "", titleStyle.Render("Qué hacer"), textStyle.Width(width).Render(e.Fix))
+	}
+	if e.Detail != "" {
+		lines = append(lines, "", dimStyle.Width(width).Render(e.Detail))
+	}
+	lines = append(lines, "", dimStyle.Render("enter/esc: volver al menú • q: salir"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func (e *ErrorView) SetTheme(theme *theme.Theme) {
+	e.theme = theme
+}
+
+func (e *ErrorView) SetSize(width, height int) {
+	e.width = width
+	e.height = height
+}
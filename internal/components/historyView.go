@@ -0,0 +1,110 @@
+package components
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fgonzalezurriola/dccprint/internal/history"
+	"github.com/fgonzalezurriola/dccprint/internal/theme"
+)
+
+// Rows of the history shown at once
+const historyRows = 12
+
+// HistoryView lists the jobs printed from this computer, newest first
+type HistoryView struct {
+	entries       []history.Entry
+	cursor        int
+	offset        int
+	StatusMessage string
+	theme         *theme.Theme
+	width         int
+	height        int
+}
+
+func NewHistoryView(theme *theme.Theme) HistoryView {
+	return HistoryView{theme: theme}
+}
+
+// SetEntries replaces the listing with entries, given oldest first as stored
+func (h *HistoryView) SetEntries(entries []history.Entry) {
+	h.entries = make([]history.Entry, len(entries))
+	for i, e := range entries {
+		h.entries[len(entries)-1-i] = e
+	}
+	h.cursor = 0
+	h.offset = 0
+}
+
+// SelectedEntry returns the entry under the cursor
+func (h HistoryView) SelectedEntry() (history.Entry, bool) {
+	if h.cursor >= len(h.entries) {
+		return history.Entry{}, false
+	}
+	return h.entries[h.cursor], true
+}
+
+func (h HistoryView) Init() tea.Cmd {
+	return nil
+}
+
+func (h HistoryView) Update(msg tea.Msg) (HistoryView, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up", "k":
+			if h.cursor > 0 {
+				h.cursor--
+			}
+		case "down", "j":
+			if h.cursor < len(h.entries)-1 {
+				h.cursor++
+			}
+		}
+	}
+	// Keep the cursor inside the visible rows
+	if h.cursor < h.offset {
+		h.offset = h.cursor
+	} else if h.cursor >= h.offset+historyRows {
+		h.offset = h.cursor - historyRows + 1
+	}
+	return h, nil
+}
+
+func (h HistoryView) View() string {
+	dimStyle := lipgloss.NewStyle().Foreground(h.theme.Unselected)
+
+	var lines []string
+	if len(h.entries) == 0 {
+		lines = append(lines, dimStyle.Render("Todavía no has impreso nada con dccprint"))
+	}
+
+	end := min(h.offset+historyRows, len(h.entries))
+	for i := h.offset; i < end; i++ {
+		cursor := " "
+		textStyle := dimStyle
+		if h.cursor == i {
+			cursor = lipgloss.NewStyle().Foreground(h.theme.Selected).Render(">")
+			textStyle = lipgloss.NewStyle().Foreground(h.theme.Selected)
+		}
+		lines = append(lines, cursor+" "+textStyle.Render(h.entries[i].String()))
+	}
+	if len(h.entries) > historyRows {
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%d-%d de %d", h.offset+1, end, len(h.entries))))
+	}
+
+	if h.StatusMessage != "" {
+		lines = append(lines, "", dimStyle.Bold(true).Render(h.StatusMessage))
+	}
+	lines = append(lines, "", dimStyle.Render("enter: reimprimir con la misma configuración • esc: volver"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func (h *HistoryView) SetTheme(theme *theme.Theme) {
+	h.theme = theme
+}
+
+func (h *HistoryView) SetSize(width, height int) {
+	h.width = width
+	h.height = height
+}
@@ -0,0 +1,82 @@
+package components
+
+import (
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/fgonzalezurriola/dccprint/internal/pdf"
+	"github.com/fgonzalezurriola/dccprint/internal/theme"
+)
+
+// LayoutView picks how many pages are printed on each side of the sheet
+type LayoutView struct {
+	Menu
+	Border bool
+	// Signature is the pages of each booklet signature, 0 for a single one
+	Signature int
+}
+
+// signatureSizes are the booklet signatures offered, cycled with c
+var signatureSizes = []int{0, 8, 12, 16, 20, 24, 32}
+
+func NewLayoutView(theme *theme.Theme) LayoutView {
+	var items []string
+	for _, n := range pdf.NUpSizes {
+		if n == 1 {
+			items = append(items, "1 página por cara")
+		} else {
+			items = append(items, fmt.Sprintf("%d páginas por cara", n))
+		}
+	}
+	return LayoutView{Menu: NewMenu(items, theme)}
+}
+
+// SetLayout moves the cursor to nup and sets the border, as saved in the config
+func (v *LayoutView) SetLayout(nup int, border bool) {
+	v.cursor = max(slices.Index(pdf.NUpSizes, nup), 0)
+	v.Border = border
+}
+
+// NUp returns the pages per side under the cursor
+func (v *LayoutView) NUp() int {
+	return pdf.NUpSizes[v.cursor]
+}
+
+func (v LayoutView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "b":
+			v.Border = !v.Border
+			return v, nil
+		case "c":
+			next := (slices.Index(signatureSizes, v.Signature) + 1) % len(signatureSizes)
+			v.Signature = signatureSizes[next]
+			return v, nil
+		}
+	}
+	newMenu, cmd := v.Menu.Update(msg)
+	v.Menu = newMenu.(Menu)
+	return v, cmd
+}
+
+func (v LayoutView) View() string {
+	dimStyle := lipgloss.NewStyle().Foreground(v.theme.Unselected)
+	border := "no"
+	if v.Border {
+		border = "sí"
+	}
+	signature := "todo junto"
+	if v.Signature > 0 {
+		signature = fmt.Sprintf("de %d páginas", v.Signature)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		v.Menu.View(),
+		dimStyle.Render("Bordes alrededor de cada página: "+border),
+		dimStyle.Render("Cuadernillos del modo folleto: "+signature),
+		"",
+		dimStyle.Render("b: bordes • c: cuadernillos • enter: guardar • esc: volver"),
+	)
+}
@@ -2,29 +2,121 @@ package components
 
 import (
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/fgonzalezurriola/dccprint/internal/components/scripts"
 	"github.com/fgonzalezurriola/dccprint/internal/theme"
 )
 
+// PrintAction is what the user asked to do with the selected file
+type PrintAction int
+
+const (
+	ActionNone PrintAction = iota
+	ActionSend
+	ActionScript
+)
+
+// Validity is the result of checking a listed pdf in the background
+type Validity int
+
+const (
+	Unchecked Validity = iota
+	Valid
+	Broken
+)
+
+// countingPages marks a file whose pages are still being counted
+const countingPages = -2
+
+// PageCountMsg carries the pages of a marked file, counted in the background
+// because converting it may take a while
+type PageCountMsg struct {
+	Path  string
+	Pages int
+}
+
+// PrintView is a file browser to pick the file to print
 type PrintView struct {
-	pdfs          []string
+	dir           string
+	entries       []scripts.Entry
+	dirErr        error
+	recentDirs    []string
+	recentIndex   int
 	cursor        int
 	selectedItem  string
 	theme         *theme.Theme
 	width         int
 	height        int
 	StatusMessage string
+	action        PrintAction
+	askingPages   bool
+	confirmed     bool
+	pagesInput    textinput.Model
+	pages         scripts.PageRange
+	pagesError    string
+	// marked maps the full path of every file toggled with space to its page
+	// count, -1 when it couldn't be counted and countingPages meanwhile
+	marked map[string]int
+	// validity holds the pdfs already checked, by full path
+	validity map[string]Validity
+	spinner  spinner.Model
+	busy     string
+	// dryRun shows what a job would do instead of printing it
+	dryRun bool
+	// overlays removes the Beamer overlays, keeping the last step of each frame
+	overlays bool
+	// copies of each job and whether they are collated, from the config at first
+	copies  int
+	collate bool
+}
+
+// NewPrintView opens the browser in dir, or in the working directory when dir is empty
+func NewPrintView(dir string, theme *theme.Theme) PrintView {
+	ti := textinput.New()
+	ti.Placeholder = "Todas (ej: 1-5,8,10-)"
+	ti.CharLimit = 64
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(theme.Selected)
+	ti.TextStyle = lipgloss.NewStyle().Foreground(theme.Header)
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = lipgloss.NewStyle().Foreground(theme.Selected)
+
+	s := PrintView{
+		theme:      theme,
+		pagesInput: ti,
+		marked:     map[string]int{},
+		validity:   map[string]Validity{},
+		spinner:    sp,
+	}
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	s.chdir(dir)
+	return s
 }
 
-func NewPrintView(pdfs []string, theme *theme.Theme) PrintView {
-	return PrintView{
-		pdfs:  pdfs,
-		theme: theme,
+// chdir moves the browser to dir, staying in place if it can't be read
+func (s *PrintView) chdir(dir string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	entries, err := scripts.ListDir(dir)
+	s.dirErr = err
+	if err != nil && s.dir != "" {
+		return
 	}
+	s.dir = dir
+	s.entries = entries
+	s.cursor = 0
 }
 
 func (s PrintView) Init() tea.Cmd {
@@ -32,40 +124,119 @@ func (s PrintView) Init() tea.Cmd {
 }
 
 func (s PrintView) View() string {
-	var currDir, err = os.Getwd()
-	if err != nil {
-		log.Fatalf("Error in PrintView View(): %v", err)
-	}
-
-	if len(s.pdfs) == 0 {
-		return fmt.Sprintf("PDFs no encontrados en %s", currDir)
-	}
-
 	if s.StatusMessage != "" {
 		msgStyle := lipgloss.NewStyle().Foreground(s.theme.Unselected).Bold(true)
 		return msgStyle.Render(s.StatusMessage)
 	}
 
-	var lines []string
-	for i, pdf := range s.pdfs {
+	if s.askingPages {
+		return s.viewPages()
+	}
+
+	titleStyle := lipgloss.NewStyle().Foreground(s.theme.Selected).Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(s.theme.Unselected)
+
+	lines := []string{titleStyle.Render(shortenHome(s.dir)), ""}
+	if len(s.entries) == 0 {
+		lines = append(lines, dimStyle.Render("No hay archivos para imprimir en "+s.dir))
+	}
+	for i, entry := range s.entries {
 		cursor := " "
-		textStyle := lipgloss.NewStyle().Foreground(s.theme.Unselected)
+		textStyle := dimStyle
 
 		if s.cursor == i {
 			cursor = lipgloss.NewStyle().Foreground(s.theme.Selected).Render(">")
 			textStyle = lipgloss.NewStyle().Foreground(s.theme.Selected)
 		}
+		name := entry.Name
+		if entry.Dir {
+			name += "/"
+		} else if _, ok := s.marked[filepath.Join(s.dir, entry.Name)]; ok {
+			name = "[x] " + name
+		} else {
+			name = "[ ] " + name
+		}
+		name += s.badge(entry)
 		line := lipgloss.JoinHorizontal(lipgloss.Left,
 			cursor,
 			" ",
-			textStyle.Render(pdf),
+			textStyle.Render(name),
 		)
 		lines = append(lines, line)
 	}
+	if s.dirErr != nil {
+		lines = append(lines, "", dimStyle.Bold(true).Render(s.dirErr.Error()))
+	}
+	if len(s.marked) > 0 {
+		lines = append(lines, "", titleStyle.Render(s.markedSummary()))
+	}
+
+	if s.copies > 1 {
+		collate := "sin intercalar"
+		if s.collate {
+			collate = "intercaladas"
+		}
+		lines = append(lines, "", titleStyle.Render(fmt.Sprintf("Copias: %d, %s", s.copies, collate)))
+	}
+	if s.overlays {
+		lines = append(lines, "", titleStyle.Render("Overlays: se imprime solo el último paso de cada diapositiva"))
+	}
+	if s.dryRun {
+		lines = append(lines, "", titleStyle.Render("Simulación activada: no se imprime ni se escribe nada"))
+	}
+	if s.busy != "" {
+		lines = append(lines, "", s.viewBusy())
+	}
+
+	help := dimStyle.Render("enter: abrir/imprimir por SSH • espacio: marcar • g: generar script • s: simular • o: overlays • +/-: copias • i: intercalar • backspace: subir • ~: home • tab: recientes • esc: volver")
+	lines = append(lines, "", help)
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// badge marks the listed pdfs as valid, broken or not checked yet
+func (s PrintView) badge(entry scripts.Entry) string {
+	if entry.Dir || !scripts.IsPDF(entry.Name) {
+		return ""
+	}
+	switch s.validity[filepath.Join(s.dir, entry.Name)] {
+	case Valid:
+		return " ✓"
+	case Broken:
+		return " ✗ dañado"
+	}
+	return " ?"
+}
+
+func (s PrintView) viewBusy() string {
+	return s.spinner.View() + " " + lipgloss.NewStyle().Foreground(s.theme.Unselected).Render(s.busy)
+}
+
+func (s PrintView) viewPages() string {
+	title := lipgloss.NewStyle().Foreground(s.theme.Selected).Bold(true).Render(filepath.Base(s.selectedItem))
+	info := "Páginas a imprimir, deja vacío para imprimir todo"
+	lines := []string{title, info, "", s.pagesInput.View()}
+	if s.pagesError != "" {
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(s.theme.Unselected).Bold(true).Render(s.pagesError))
+	}
+	help := lipgloss.NewStyle().Foreground(s.theme.Unselected).Render("enter: continuar • esc: elegir otro archivo")
+	lines = append(lines, "", help)
 	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
 func (s PrintView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// The spinner keeps turning only while something runs in the background
+	if tick, ok := msg.(spinner.TickMsg); ok {
+		if s.busy == "" {
+			return s, nil
+		}
+		var cmd tea.Cmd
+		s.spinner, cmd = s.spinner.Update(tick)
+		return s, cmd
+	}
+	if s.askingPages {
+		return s.updatePages(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -74,12 +245,45 @@ func (s PrintView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				s.cursor--
 			}
 		case "down", "j":
-			if s.cursor < len(s.pdfs)-1 {
+			if s.cursor < len(s.entries)-1 {
 				s.cursor++
 			}
 		case "enter":
-			if len(s.pdfs) > 0 {
-				s.selectedItem = s.pdfs[s.cursor]
+			if entry, ok := s.current(); ok && entry.Dir {
+				s.chdir(filepath.Join(s.dir, entry.Name))
+				return s, nil
+			}
+			// A batch prints every page of every marked file
+			if len(s.marked) > 0 {
+				s.action = ActionSend
+				s.confirmed = true
+				return s, nil
+			}
+			return s.askPages(ActionSend)
+		case " ":
+			return s, s.toggleMark()
+		case "g":
+			return s.askPages(ActionScript)
+		case "s":
+			s.dryRun = !s.dryRun
+		case "o":
+			s.overlays = !s.overlays
+		case "+":
+			s.copies = min(max(s.copies, 1)+1, scripts.MaxCopies)
+		case "-":
+			s.copies = max(s.copies-1, 1)
+		case "i":
+			s.collate = !s.collate
+		case "backspace":
+			s.chdir(filepath.Dir(s.dir))
+		case "~":
+			if home, err := os.UserHomeDir(); err == nil {
+				s.chdir(home)
+			}
+		case "tab":
+			if len(s.recentDirs) > 0 {
+				s.chdir(s.recentDirs[s.recentIndex%len(s.recentDirs)])
+				s.recentIndex++
 			}
 		case "ctrl+c", "q":
 			return s, tea.Quit
@@ -88,17 +292,258 @@ func (s PrintView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return s, nil
 }
 
+// toggleMark adds or removes the file under the cursor from the batch,
+// returning the command that counts the pages of a file just marked
+func (s *PrintView) toggleMark() tea.Cmd {
+	entry, ok := s.current()
+	if !ok || entry.Dir {
+		return nil
+	}
+	path := filepath.Join(s.dir, entry.Name)
+	if _, ok := s.marked[path]; ok {
+		delete(s.marked, path)
+		return nil
+	}
+	s.marked[path] = countingPages
+	if s.cursor < len(s.entries)-1 {
+		s.cursor++
+	}
+	return func() tea.Msg {
+		pages, err := scripts.CountPages(path)
+		if err != nil {
+			pages = -1
+		}
+		return PageCountMsg{Path: path, Pages: pages}
+	}
+}
+
+// SetPageCount fills in the pages of a file, if it is still marked
+func (s *PrintView) SetPageCount(msg PageCountMsg) {
+	if _, ok := s.marked[msg.Path]; ok {
+		s.marked[msg.Path] = msg.Pages
+	}
+}
+
+// markedSummary describes the batch, like "3 archivos marcados • 42 páginas"
+func (s PrintView) markedSummary() string {
+	total, unknown, counting := 0, 0, 0
+	for _, pages := range s.marked {
+		switch {
+		case pages == countingPages:
+			counting++
+		case pages < 0:
+			unknown++
+		default:
+			total += pages
+		}
+	}
+	summary := fmt.Sprintf("%d archivos marcados • %d páginas", len(s.marked), total)
+	if counting > 0 {
+		summary += fmt.Sprintf(" (contando %d)", counting)
+	}
+	if unknown > 0 {
+		summary += fmt.Sprintf(" (%d sin contar)", unknown)
+	}
+	return summary
+}
+
+func (s PrintView) current() (scripts.Entry, bool) {
+	if len(s.entries) == 0 {
+		return scripts.Entry{}, false
+	}
+	return s.entries[s.cursor], true
+}
+
+// askPages selects the file under the cursor and asks which pages to print
+func (s PrintView) askPages(action PrintAction) (tea.Model, tea.Cmd) {
+	entry, ok := s.current()
+	if !ok || entry.Dir {
+		return s, nil
+	}
+	s.selectedItem = filepath.Join(s.dir, entry.Name)
+	s.action = action
+	s.askingPages = true
+	s.pagesError = ""
+	s.pagesInput.Reset()
+	return s, s.pagesInput.Focus()
+}
+
+func (s PrintView) updatePages(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "enter":
+			pages, err := scripts.ParsePageRange(s.pagesInput.Value())
+			if err != nil {
+				s.pagesError = err.Error()
+				return s, nil
+			}
+			s.pages = pages
+			s.askingPages = false
+			s.confirmed = true
+			s.pagesInput.Blur()
+			return s, nil
+		case "esc":
+			s.askingPages = false
+			s.selectedItem = ""
+			s.pagesInput.Blur()
+			return s, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	s.pagesInput, cmd = s.pagesInput.Update(msg)
+	return s, cmd
+}
+
+// shortenHome replaces the home directory prefix with ~
+func shortenHome(dir string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if strings.HasPrefix(dir, home+string(filepath.Separator)) {
+		return "~" + dir[len(home):]
+	}
+	return dir
+}
+
+// UncheckedPDFs returns the full path of the listed pdfs not validated yet
+func (s *PrintView) UncheckedPDFs() []string {
+	var paths []string
+	for _, entry := range s.entries {
+		path := filepath.Join(s.dir, entry.Name)
+		if _, ok := s.validity[path]; !entry.Dir && scripts.IsPDF(entry.Name) && !ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// SetValidity stores the result of validating the pdf at path
+func (s *PrintView) SetValidity(path string, v Validity) {
+	s.validity[path] = v
+}
+
+// SetBusy shows text next to a spinner, an empty text hides it
+func (s *PrintView) SetBusy(text string) tea.Cmd {
+	idle := s.busy == ""
+	s.busy = text
+	if idle && text != "" {
+		return s.spinner.Tick
+	}
+	return nil
+}
+
+// Busy reports whether the spinner is shown
+func (s *PrintView) Busy() bool {
+	return s.busy != ""
+}
+
+// SelectedItem returns the full path of the chosen file
 func (s *PrintView) SelectedItem() string {
 	return s.selectedItem
 }
 
+// SelectedItems returns the marked files in order, or the chosen file when nothing is marked
+func (s *PrintView) SelectedItems() []string {
+	if len(s.marked) == 0 {
+		return []string{s.selectedItem}
+	}
+	paths := make([]string, 0, len(s.marked))
+	for path := range s.marked {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ClearMarks empties the batch
+func (s *PrintView) ClearMarks() {
+	s.marked = map[string]int{}
+}
+
+// Err returns why the current directory couldn't be listed
+func (s *PrintView) Err() error {
+	return s.dirErr
+}
+
+// Dir returns the directory being browsed
+func (s *PrintView) Dir() string {
+	return s.dir
+}
+
+// SetRecentDirs sets the directories cycled with tab
+func (s *PrintView) SetRecentDirs(dirs []string) {
+	s.recentDirs = dirs
+	s.recentIndex = 0
+}
+
+// AskingPages reports whether the page range input has the focus
+func (s *PrintView) AskingPages() bool {
+	return s.askingPages
+}
+
+// Confirmed reports whether a file and its page range are ready to print
+func (s *PrintView) Confirmed() bool {
+	return s.confirmed
+}
+
+func (s *PrintView) Action() PrintAction {
+	return s.action
+}
+
+// DryRun reports whether confirmed jobs should only be explained
+func (s *PrintView) DryRun() bool {
+	return s.dryRun
+}
+
+// Overlays reports whether confirmed jobs should remove the Beamer overlays
+func (s *PrintView) Overlays() bool {
+	return s.overlays
+}
+
+// SetCopies sets the copies and collation jobs start with
+func (s *PrintView) SetCopies(copies int, collate bool) {
+	s.copies = max(copies, 1)
+	s.collate = collate
+}
+
+// Copies returns the copies of each job and whether they are collated
+func (s *PrintView) Copies() (int, bool) {
+	return s.copies, s.collate
+}
+
+func (s *PrintView) Pages() scripts.PageRange {
+	return s.pages
+}
+
+// Reset clears the selection and reloads the current directory.
+// Files may have changed since, so they are validated again.
 func (s *PrintView) Reset() {
 	s.selectedItem = ""
-	s.cursor = 0
+	s.validity = map[string]Validity{}
+	s.action = ActionNone
+	s.askingPages = false
+	s.confirmed = false
+	s.pages = nil
+	s.pagesError = ""
+	s.pagesInput.Reset()
+	s.pagesInput.Blur()
+	cursor := s.cursor
+	s.chdir(s.dir)
+	if cursor < len(s.entries) {
+		s.cursor = cursor
+	}
 }
 
 func (s *PrintView) SetTheme(theme *theme.Theme) {
 	s.theme = theme
+	s.pagesInput.PromptStyle = lipgloss.NewStyle().Foreground(theme.Selected)
+	s.pagesInput.TextStyle = lipgloss.NewStyle().Foreground(theme.Header)
+	s.spinner.Style = lipgloss.NewStyle().Foreground(theme.Selected)
 }
 
 func (s *PrintView) SetSize(width, height int) {
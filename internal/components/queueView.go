@@ -0,0 +1,153 @@
+package components
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fgonzalezurriola/dccprint/internal/queue"
+	"github.com/fgonzalezurriola/dccprint/internal/theme"
+)
+
+// QueueView lists the jobs waiting in every printer
+type QueueView struct {
+	queues        []queue.Queue
+	account       string
+	cursor        int
+	Loading       bool
+	StatusMessage string
+	updatedAt     time.Time
+	theme         *theme.Theme
+	width         int
+	height        int
+}
+
+func NewQueueView(theme *theme.Theme) QueueView {
+	return QueueView{theme: theme}
+}
+
+// SetQueues replaces the listing, keeping the cursor inside the new jobs
+func (q *QueueView) SetQueues(queues []queue.Queue, account string) {
+	q.queues = queues
+	q.account = account
+	q.updatedAt = time.Now()
+	if total := q.jobCount(); q.cursor >= total {
+		q.cursor = max(total-1, 0)
+	}
+}
+
+func (q QueueView) jobCount() int {
+	total := 0
+	for _, queue := range q.queues {
+		total += len(queue.Jobs)
+	}
+	return total
+}
+
+// SelectedJob returns the job under the cursor and the printer that holds it
+func (q QueueView) SelectedJob() (string, queue.Job, bool) {
+	i := q.cursor
+	for _, queue := range q.queues {
+		if i < len(queue.Jobs) {
+			return queue.Printer, queue.Jobs[i], true
+		}
+		i -= len(queue.Jobs)
+	}
+	return "", queue.Job{}, false
+}
+
+// Owned reports whether job belongs to the configured account
+func (q QueueView) Owned(job queue.Job) bool {
+	return job.Owner == q.account
+}
+
+func (q QueueView) Init() tea.Cmd {
+	return nil
+}
+
+func (q QueueView) Update(msg tea.Msg) (QueueView, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up", "k":
+			if q.cursor > 0 {
+				q.cursor--
+			}
+		case "down", "j":
+			if q.cursor < q.jobCount()-1 {
+				q.cursor++
+			}
+		}
+	}
+	return q, nil
+}
+
+func (q QueueView) View() string {
+	titleStyle := lipgloss.NewStyle().Foreground(q.theme.Selected).Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(q.theme.Unselected)
+
+	var lines []string
+	if q.Loading && q.queues == nil {
+		lines = append(lines, dimStyle.Render("Consultando la cola de impresión..."))
+	}
+
+	i := 0
+	for _, queue := range q.queues {
+		lines = append(lines, titleStyle.Render(queue.Printer)+" "+dimStyle.Render(queue.Status))
+		if len(queue.Jobs) == 0 {
+			lines = append(lines, dimStyle.Render("  Sin trabajos"))
+		}
+		for _, job := range queue.Jobs {
+			cursor := " "
+			textStyle := dimStyle
+			if q.cursor == i {
+				cursor = lipgloss.NewStyle().Foreground(q.theme.Selected).Render(">")
+				textStyle = lipgloss.NewStyle().Foreground(q.theme.Selected)
+			}
+			owner := job.Owner
+			if q.Owned(job) {
+				owner += "*"
+			}
+			row := fmt.Sprintf("%-7s %-10s %-6d %-28.28s %8s", job.Rank, owner, job.ID, job.File, formatSize(job.Size))
+			lines = append(lines, cursor+" "+textStyle.Render(row))
+			i++
+		}
+		lines = append(lines, "")
+	}
+
+	if !q.updatedAt.IsZero() {
+		lines = append(lines, dimStyle.Render("Actualizado "+q.updatedAt.Format("15:04:05")))
+	}
+	if q.StatusMessage != "" {
+		lines = append(lines, dimStyle.Bold(true).Render(q.StatusMessage))
+	}
+	lines = append(lines, "", dimStyle.Render("x: cancelar trabajo propio (*) • r: actualizar • esc: volver"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// Reset clears the listing so the next visit starts loading
+func (q *QueueView) Reset() {
+	q.queues = nil
+	q.cursor = 0
+	q.StatusMessage = ""
+	q.updatedAt = time.Time{}
+}
+
+func (q *QueueView) SetTheme(theme *theme.Theme) {
+	q.theme = theme
+}
+
+func (q *QueueView) SetSize(width, height int) {
+	q.width = width
+	q.height = height
+}
@@ -0,0 +1,148 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fgonzalezurriola/dccprint/internal/theme"
+)
+
+// Lines of remote output kept on screen
+const remoteViewLines = 15
+
+// RemoteView shows the output of a command running in anakena and
+// asks for the password when the server requests it.
+type RemoteView struct {
+	Title         string
+	StatusMessage string
+	items         []remoteItem
+	lines         []string
+	input         textinput.Model
+	question      string
+	prompting     bool
+	theme         *theme.Theme
+	width         int
+	height        int
+}
+
+// remoteItem is a file of a batch with its current status
+type remoteItem struct {
+	name   string
+	status string
+}
+
+func NewRemoteView(theme *theme.Theme) RemoteView {
+	r := RemoteView{theme: theme}
+	r.input = textinput.New()
+	r.SetTheme(theme)
+	return r
+}
+
+// Start clears the view for a new remote operation
+func (r *RemoteView) Start(title string) {
+	r.Title = title
+	r.StatusMessage = ""
+	r.items = nil
+	r.lines = nil
+	r.prompting = false
+	r.input.Reset()
+	r.input.Blur()
+}
+
+// SetItems lists the files of a batch, all of them with status
+func (r *RemoteView) SetItems(names []string, status string) {
+	r.items = make([]remoteItem, len(names))
+	for i, name := range names {
+		r.items[i] = remoteItem{name: name, status: status}
+	}
+}
+
+func (r *RemoteView) SetItemStatus(i int, status string) {
+	if i >= 0 && i < len(r.items) {
+		r.items[i].status = status
+	}
+}
+
+func (r *RemoteView) AppendLine(line string) {
+	r.lines = append(r.lines, line)
+	if len(r.lines) > remoteViewLines {
+		r.lines = r.lines[len(r.lines)-remoteViewLines:]
+	}
+}
+
+// Ask shows question with an input, masked unless echo is true
+func (r *RemoteView) Ask(question string, echo bool) tea.Cmd {
+	r.question = question
+	r.prompting = true
+	r.input.Reset()
+	r.input.EchoMode = textinput.EchoPassword
+	r.input.EchoCharacter = '•'
+	if echo {
+		r.input.EchoMode = textinput.EchoNormal
+	}
+	return r.input.Focus()
+}
+
+// Answer returns the typed value and hides the input
+func (r *RemoteView) Answer() string {
+	value := r.input.Value()
+	r.prompting = false
+	r.input.Reset()
+	r.input.Blur()
+	return value
+}
+
+func (r RemoteView) Prompting() bool {
+	return r.prompting
+}
+
+func (r RemoteView) Init() tea.Cmd {
+	return nil
+}
+
+func (r RemoteView) Update(msg tea.Msg) (RemoteView, tea.Cmd) {
+	if !r.prompting {
+		return r, nil
+	}
+	var cmd tea.Cmd
+	r.input, cmd = r.input.Update(msg)
+	return r, cmd
+}
+
+func (r RemoteView) View() string {
+	titleStyle := lipgloss.NewStyle().Foreground(r.theme.Selected).Bold(true)
+	outputStyle := lipgloss.NewStyle().Foreground(r.theme.Unselected)
+
+	parts := []string{titleStyle.Render(r.Title), ""}
+	if len(r.items) > 0 {
+		var items []string
+		for _, item := range r.items {
+			items = append(items, fmt.Sprintf("%-12s %s", "["+item.status+"]", item.name))
+		}
+		parts = append(parts, lipgloss.NewStyle().Foreground(r.theme.Selected).Render(strings.Join(items, "\n")), "")
+	}
+	if len(r.lines) > 0 {
+		parts = append(parts, outputStyle.Render(strings.Join(r.lines, "\n")), "")
+	}
+	if r.prompting {
+		parts = append(parts, r.question, r.input.View())
+	}
+	if r.StatusMessage != "" {
+		parts = append(parts, lipgloss.NewStyle().Foreground(r.theme.Unselected).Bold(true).Render(r.StatusMessage))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
+}
+
+func (r *RemoteView) SetTheme(theme *theme.Theme) {
+	r.theme = theme
+	r.input.PromptStyle = lipgloss.NewStyle().Foreground(theme.Selected)
+	r.input.TextStyle = lipgloss.NewStyle().Foreground(theme.Header)
+}
+
+func (r *RemoteView) SetSize(width, height int) {
+	r.width = width
+	r.height = height
+}
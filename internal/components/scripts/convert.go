@@ -0,0 +1,401 @@
+package scripts
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fgonzalezurriola/dccprint/internal/pdf"
+)
+
+// Converter renders a file of some format as a pdf that can be printed
+type Converter interface {
+	Convert(src string) ([]byte, error)
+}
+
+type plainTextConverter struct{}
+type sourceConverter struct{}
+type markdownConverter struct{}
+type jpegConverter struct{}
+type pngConverter struct{}
+
+// converters are indexed by lowercase extension
+var converters = map[string]Converter{
+	".txt": plainTextConverter{}, ".log": plainTextConverter{}, ".csv": plainTextConverter{},
+	".md": markdownConverter{}, ".markdown": markdownConverter{},
+	".jpg": jpegConverter{}, ".jpeg": jpegConverter{},
+	".png": pngConverter{},
+}
+
+func init() {
+	for _, ext := range []string{
+		".c", ".h", ".cc", ".cpp", ".hpp", ".java", ".py", ".go", ".rs", ".js", ".ts",
+		".rb", ".sh", ".hs", ".ml", ".scala", ".kt", ".sql", ".tex", ".css", ".html",
+		".json", ".yaml", ".yml", ".xml", ".r", ".lua", ".php", ".swift", ".asm", ".s",
+	} {
+		converters[ext] = sourceConverter{}
+	}
+}
+
+// IsPDF reports whether name has a .pdf extension, in any case
+func IsPDF(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".pdf")
+}
+
+// Supported reports whether name is a pdf or can be converted to one
+func Supported(name string) bool {
+	if IsPDF(name) {
+		return true
+	}
+	_, ok := converters[strings.ToLower(filepath.Ext(name))]
+	return ok
+}
+
+// ConvertToPDF returns a pdf with the contents of src. Pdfs are returned as is,
+// other formats are converted into a temporary file removed by cleanup.
+func ConvertToPDF(src string) (path string, cleanup func(), err error) {
+	if IsPDF(src) {
+		return src, func() {}, nil
+	}
+	converter, ok := converters[strings.ToLower(filepath.Ext(src))]
+	if !ok {
+		return "", nil, fmt.Errorf("formato no soportado: %s", filepath.Base(src))
+	}
+
+	data, err := converter.Convert(src)
+	if err != nil {
+		return "", nil, fmt.Errorf("error convirtiendo %s a pdf: %w", filepath.Base(src), err)
+	}
+	return writeTempPDF(data)
+}
+
+// writeTempPDF stores data in a temporary file removed by cleanup
+func writeTempPDF(data []byte) (path string, cleanup func(), err error) {
+	tmp, err := os.CreateTemp("", "dccprint-*.pdf")
+	if err != nil {
+		return "", nil, fmt.Errorf("error creando archivo temporal: %w", err)
+	}
+	cleanup = func() { os.Remove(tmp.Name()) }
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("error creando archivo temporal: %w", err)
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+// RenderPDF returns the bytes of src as a pdf without writing anything to disk
+func RenderPDF(src string) ([]byte, error) {
+	if IsPDF(src) {
+		return os.ReadFile(src)
+	}
+	converter, ok := converters[strings.ToLower(filepath.Ext(src))]
+	if !ok {
+		return nil, fmt.Errorf("formato no soportado: %s", filepath.Base(src))
+	}
+	data, err := converter.Convert(src)
+	if err != nil {
+		return nil, fmt.Errorf("error convirtiendo %s a pdf: %w", filepath.Base(src), err)
+	}
+	return data, nil
+}
+
+// --- Text ---
+
+// A4 in points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	pageMargin = 56.0
+)
+
+// Standard Courier fonts, monospaced so lines can be wrapped by counting runes
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+	fontItalic  = "F3"
+)
+
+type textLine struct {
+	font string
+	size float64
+	text string
+}
+
+func (plainTextConverter) Convert(src string) ([]byte, error) {
+	lines, err := readLines(src)
+	if err != nil {
+		return nil, err
+	}
+	var out []textLine
+	for _, line := range lines {
+		out = append(out, textLine{fontRegular, 10, line})
+	}
+	return writeTextPDF("", out)
+}
+
+func (sourceConverter) Convert(src string) ([]byte, error) {
+	lines, err := readLines(src)
+	if err != nil {
+		return nil, err
+	}
+	digits := len(fmt.Sprint(len(lines)))
+	var out []textLine
+	for i, line := range lines {
+		out = append(out, textLine{fontRegular, 9, fmt.Sprintf("%*d  %s", digits, i+1, line)})
+	}
+	return writeTextPDF(filepath.Base(src), out)
+}
+
+var (
+	mdEmphasisRe = regexp.MustCompile("\\*\\*|__|`")
+	mdLinkRe     = regexp.MustCompile(`!?\[([^\]]*)\]\(([^)]*)\)`)
+	mdHeadingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdBulletRe   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+)
+
+// Convert renders headings in bold, code blocks verbatim and strips inline markup
+func (markdownConverter) Convert(src string) ([]byte, error) {
+	lines, err := readLines(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []textLine
+	inCode := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out = append(out, textLine{fontRegular, 9, "    " + line})
+			continue
+		}
+
+		line = mdLinkRe.ReplaceAllString(line, "$1 ($2)")
+		line = mdEmphasisRe.ReplaceAllString(line, "")
+		if m := mdHeadingRe.FindStringSubmatch(line); m != nil {
+			size := 16 - 2*float64(len(m[1])-1)
+			out = append(out, textLine{fontBold, max(size, 10), m[2]}, textLine{fontRegular, 6, ""})
+			continue
+		}
+		if m := mdBulletRe.FindStringSubmatch(line); m != nil {
+			line = m[1] + "• " + m[2]
+		}
+		if strings.HasPrefix(line, ">") {
+			out = append(out, textLine{fontItalic, 10, "  " + strings.TrimSpace(strings.TrimPrefix(line, ">"))})
+			continue
+		}
+		out = append(out, textLine{fontRegular, 10, line})
+	}
+	return writeTextPDF("", out)
+}
+
+// readLines reads a text file, accepting Latin-1 when it isn't valid UTF-8
+func readLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		data = []byte(string(runes))
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		lines = append(lines, expandTabs(strings.TrimRight(scanner.Text(), "\r"), 4))
+	}
+	return lines, scanner.Err()
+}
+
+func expandTabs(line string, width int) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := width - col%width
+			b.WriteString(strings.Repeat(" ", spaces))
+			col += spaces
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
+
+// wrap splits text so every piece fits in the page width at size points
+func wrap(text string, size float64) []string {
+	maxChars := int((pageWidth - 2*pageMargin) / (0.6 * size))
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return []string{text}
+	}
+	var parts []string
+	for len(runes) > maxChars {
+		cut := maxChars
+		// Prefer breaking on a space when there is one in the second half
+		for i := maxChars; i > maxChars/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		parts = append(parts, string(runes[:cut]))
+		runes = runes[cut:]
+		if len(runes) > 0 && runes[0] == ' ' {
+			runes = runes[1:]
+		}
+	}
+	return append(parts, string(runes))
+}
+
+// writeTextPDF lays out lines over A4 pages. A non empty header is printed
+// on top of every page with the page number.
+func writeTextPDF(header string, lines []textLine) ([]byte, error) {
+	w := pdf.NewWriter()
+	parent := w.Reserve()
+	fonts := fmt.Sprintf("<< /Font << /%s %s /%s %s /%s %s >> >>",
+		fontRegular, w.Add("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"),
+		fontBold, w.Add("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>"),
+		fontItalic, w.Add("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Oblique /Encoding /WinAnsiEncoding >>"))
+	resources := w.Add(fonts)
+
+	var pages []pdf.Ref
+	var content strings.Builder
+	top := pageHeight - pageMargin
+	if header != "" {
+		top -= 20
+	}
+	y := top
+
+	flush := func() {
+		if header != "" {
+			fmt.Fprintf(&content, "BT /%s 8 Tf %.2f %.2f Td %s Tj ET\n", fontBold, pageMargin, pageHeight-pageMargin,
+				pdf.Text(fmt.Sprintf("%s - página %d", header, len(pages)+1)))
+		}
+		stream := w.AddCompressedStream("", []byte(content.String()))
+		page := w.Add(fmt.Sprintf("<< /Type /Page /Parent %s /MediaBox [0 0 %g %g] /Resources %s /Contents %s >>",
+			parent, pageWidth, pageHeight, resources, stream))
+		pages = append(pages, page)
+		content.Reset()
+		y = top
+	}
+
+	for _, line := range lines {
+		for _, part := range wrap(line.text, line.size) {
+			step := line.size * 1.25
+			if y-step < pageMargin {
+				flush()
+			}
+			y -= step
+			if part != "" {
+				fmt.Fprintf(&content, "BT /%s %g Tf %.2f %.2f Td %s Tj ET\n", line.font, line.size, pageMargin, y, pdf.Text(part))
+			}
+		}
+	}
+	if content.Len() > 0 || len(pages) == 0 {
+		flush()
+	}
+
+	catalog := w.AddPageTree(parent, pages)
+	return w.Bytes(catalog), nil
+}
+
+// --- Images ---
+
+func (jpegConverter) Convert(src string) ([]byte, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	colorSpace := "/DeviceRGB"
+	switch cfg.ColorModel {
+	case color.GrayModel:
+		colorSpace = "/DeviceGray"
+	case color.CMYKModel:
+		// Adobe writes CMYK jpegs inverted
+		colorSpace = "/DeviceCMYK /Decode [1 0 1 0 1 0 1 0]"
+	}
+
+	w := pdf.NewWriter()
+	img := w.AddStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode",
+		cfg.Width, cfg.Height, colorSpace), data)
+	return writeImagePDF(w, img, cfg.Width, cfg.Height)
+}
+
+// Convert flattens the png over white and stores it as compressed RGB
+func (pngConverter) Convert(src string) ([]byte, error) {
+	file, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	decoded, err := png.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := decoded.Bounds()
+	canvas := image.NewRGBA(bounds)
+	draw.Draw(canvas, bounds, image.White, image.Point{}, draw.Src)
+	draw.Draw(canvas, bounds, decoded, bounds.Min, draw.Over)
+
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for i := 0; i < len(canvas.Pix); i += 4 {
+		rgb = append(rgb, canvas.Pix[i], canvas.Pix[i+1], canvas.Pix[i+2])
+	}
+
+	w := pdf.NewWriter()
+	img := w.AddCompressedStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+		bounds.Dx(), bounds.Dy()), rgb)
+	return writeImagePDF(w, img, bounds.Dx(), bounds.Dy())
+}
+
+// writeImagePDF centers img on a single A4 page, in landscape for wide images
+func writeImagePDF(w *pdf.Writer, img pdf.Ref, width, height int) ([]byte, error) {
+	pw, ph := pageWidth, pageHeight
+	if width > height {
+		pw, ph = ph, pw
+	}
+
+	scale := min((pw-2*pageMargin)/float64(width), (ph-2*pageMargin)/float64(height))
+	iw, ih := float64(width)*scale, float64(height)*scale
+	x, y := (pw-iw)/2, (ph-ih)/2
+
+	parent := w.Reserve()
+	content := w.AddStream("", []byte(fmt.Sprintf("q %.2f 0 0 %.2f %.2f %.2f cm /Im1 Do Q", iw, ih, x, y)))
+	page := w.Add(fmt.Sprintf("<< /Type /Page /Parent %s /MediaBox [0 0 %g %g] /Resources << /XObject << /Im1 %s >> >> /Contents %s >>",
+		parent, pw, ph, img, content))
+
+	catalog := w.AddPageTree(parent, []pdf.Ref{page})
+	return w.Bytes(catalog), nil
+}
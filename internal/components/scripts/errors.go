@@ -0,0 +1,34 @@
+package scripts
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// InvalidPDFError is returned when a file is damaged or isn't a pdf
+type InvalidPDFError struct {
+	File string
+	Err  error
+}
+
+func (e *InvalidPDFError) Error() string {
+	return fmt.Sprintf("%s no es un pdf válido: %v", filepath.Base(e.File), e.Err)
+}
+
+func (e *InvalidPDFError) Unwrap() error {
+	return e.Err
+}
+
+// DirError is returned when a directory can't be listed
+type DirError struct {
+	Dir string
+	Err error
+}
+
+func (e *DirError) Error() string {
+	return fmt.Sprintf("no se pudo leer %s: %v", e.Dir, e.Err)
+}
+
+func (e *DirError) Unwrap() error {
+	return e.Err
+}
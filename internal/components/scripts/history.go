@@ -0,0 +1,62 @@
+package scripts
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fgonzalezurriola/dccprint/internal/history"
+)
+
+// Record appends the job and the result of sending it to the local history.
+// The history is informative, so failing to write it never fails the job.
+func (job Job) Record(sendErr error) {
+	entry := history.Entry{
+		Time:      time.Now(),
+		File:      job.File,
+		Printer:   job.Printer,
+		Mode:      job.Mode,
+		Account:   job.Account,
+		NUp:       job.NUp,
+		Border:    job.Border,
+		Collapse:  job.Collapse,
+		Copies:    job.Copies,
+		Collate:   job.Collate,
+		Signature: job.Signature,
+		Outcome:   history.OutcomeOK,
+	}
+	if abs, err := filepath.Abs(job.File); err == nil {
+		entry.File = abs
+	}
+	if job.Pages != nil {
+		entry.Range = job.Pages.String()
+	}
+	if sendErr != nil {
+		entry.Outcome = sendErr.Error()
+	}
+	entry.Hash, _ = history.HashFile(job.File)
+	if pages, err := CountPages(job.File); err == nil {
+		entry.Pages = pages
+	}
+	history.Append(entry)
+}
+
+// JobFromEntry rebuilds a job with the settings it was first printed with
+func JobFromEntry(e history.Entry) (Job, error) {
+	pages, err := ParsePageRange(e.Range)
+	if err != nil {
+		return Job{}, err
+	}
+	return Job{
+		File:      e.File,
+		Account:   e.Account,
+		Printer:   e.Printer,
+		Mode:      e.Mode,
+		Pages:     pages,
+		NUp:       e.NUp,
+		Border:    e.Border,
+		Collapse:  e.Collapse,
+		Copies:    e.Copies,
+		Collate:   e.Collate,
+		Signature: e.Signature,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package scripts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PageSpan is an inclusive range of pages. Last is 0 when the span runs to the end.
+type PageSpan struct {
+	First int
+	Last  int
+}
+
+// PageRange is a selection such as 1-5,8,10-. A nil PageRange means every page.
+type PageRange []PageSpan
+
+// ParsePageRange parses a comma separated list of pages and ranges.
+// An empty expression selects the whole document.
+func ParsePageRange(expr string) (PageRange, error) {
+	expr = strings.ReplaceAll(expr, " ", "")
+	if expr == "" {
+		return nil, nil
+	}
+
+	var r PageRange
+	for _, part := range strings.Split(expr, ",") {
+		span, err := parsePageSpan(part)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, span)
+	}
+	return r, nil
+}
+
+func parsePageSpan(part string) (PageSpan, error) {
+	if part == "" {
+		return PageSpan{}, fmt.Errorf("rango de páginas vacío")
+	}
+
+	first, last, isRange := strings.Cut(part, "-")
+	if !isRange {
+		n, err := parsePage(first)
+		if err != nil {
+			return PageSpan{}, err
+		}
+		return PageSpan{First: n, Last: n}, nil
+	}
+
+	span := PageSpan{First: 1}
+	var err error
+	if first != "" {
+		if span.First, err = parsePage(first); err != nil {
+			return PageSpan{}, err
+		}
+	}
+	if last != "" {
+		if span.Last, err = parsePage(last); err != nil {
+			return PageSpan{}, err
+		}
+		if span.Last < span.First {
+			return PageSpan{}, fmt.Errorf("rango de páginas invertido: %s", part)
+		}
+	}
+	if first == "" && last == "" {
+		return PageSpan{}, fmt.Errorf("rango de páginas inválido: %s", part)
+	}
+	return span, nil
+}
+
+func parsePage(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("número de página inválido: %q", s)
+	}
+	return n, nil
+}
+
+// String returns the range in the syntax understood by psselect
+func (r PageRange) String() string {
+	parts := make([]string, len(r))
+	for i, span := range r {
+		switch {
+		case span.Last == 0:
+			parts[i] = fmt.Sprintf("%d-", span.First)
+		case span.First == span.Last:
+			parts[i] = strconv.Itoa(span.First)
+		default:
+			parts[i] = fmt.Sprintf("%d-%d", span.First, span.Last)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Count returns how many pages the range selects in a document of total
+// pages, each page once even if several spans take it
+func (r PageRange) Count(total int) int {
+	if r == nil {
+		return total
+	}
+	return len(r.Pages(total))
+}
+
+// Pages lists the 1-based pages of a document of total pages that are in
+// the range, nil when the range takes every page
+func (r PageRange) Pages(total int) []int {
+	if r == nil {
+		return nil
+	}
+	var pages []int
+	for page := 1; page <= total; page++ {
+		for _, span := range r {
+			if page >= span.First && (span.Last == 0 || page <= span.Last) {
+				pages = append(pages, page)
+				break
+			}
+		}
+	}
+	return pages
+}
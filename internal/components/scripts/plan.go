@@ -0,0 +1,127 @@
+package scripts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fgonzalezurriola/dccprint/internal/config"
+	"github.com/fgonzalezurriola/dccprint/internal/pdf"
+)
+
+// Plan is what printing a job would do, worked out without writing to disk
+// or connecting to anakena
+type Plan struct {
+	Job     Job
+	Printer config.Printer
+	Mode    config.Mode
+	// Files are written in the job's temporary directory in anakena
+	Files []string
+	// Command is the line the generated script runs to print the job
+	Command string
+	// Total is the page count of the document, Pages the ones kept by the
+	// range and Printed those left after removing the overlays
+	Total   int
+	Pages   int
+	Printed int
+	Sheets  int
+}
+
+// Explain resolves the job and counts its pages to describe how it would print
+func (job Job) Explain() (Plan, error) {
+	printer, mode, err := job.resolve()
+	if err != nil {
+		return Plan{}, err
+	}
+	if err := config.ValidateAccount(job.Account); err != nil {
+		return Plan{}, err
+	}
+	command, err := job.RemoteCommand()
+	if err != nil {
+		return Plan{}, err
+	}
+
+	data, err := RenderPDF(job.File)
+	if err != nil {
+		return Plan{}, err
+	}
+	total, err := pdf.ValidateData(data)
+	if err != nil {
+		return Plan{}, &InvalidPDFError{File: filepath.Base(job.File), Err: err}
+	}
+	// The pages are laid out in memory only to know that it will work
+	layout := Layout{Before: job.Pages.Count(total)}
+	layout.After = layout.Before
+	if job.local() {
+		if _, layout, err = job.transform(data); err != nil {
+			return Plan{}, err
+		}
+	}
+
+	// Converted and laid out files are uploaded from a temporary pdf created when printing
+	upload := job.File
+	if !IsPDF(job.File) || job.local() {
+		upload = filepath.Join(os.TempDir(), "dccprint-*.pdf")
+	}
+
+	return Plan{
+		Job:     job,
+		Printer: printer,
+		Mode:    mode,
+		Files:   job.remoteFiles(),
+		Command: sshLine(config.Load().SSH(), upload, job.Account, command),
+		Total:   total,
+		Pages:   layout.Before,
+		Printed: layout.After,
+		Sheets:  job.sheets(layout.After),
+	}, nil
+}
+
+func (p Plan) String() string {
+	var b strings.Builder
+	queue := p.Printer.Queue
+	if queue == "" {
+		queue = "predeterminada"
+	}
+	if IsPDF(p.Job.File) {
+		fmt.Fprintf(&b, "Archivo:    %s\n", p.Job.File)
+	} else {
+		fmt.Fprintf(&b, "Archivo:    %s (se convierte a pdf al imprimir)\n", p.Job.File)
+	}
+	fmt.Fprintf(&b, "Impresora:  %s (cola %s)\n", p.Printer.Name, queue)
+	fmt.Fprintf(&b, "Modo:       %s\n", p.Mode.Name)
+	if p.Job.Pages != nil {
+		fmt.Fprintf(&b, "Páginas:    %d de %d (%s)\n", p.Pages, p.Total, p.Job.Pages)
+	} else {
+		fmt.Fprintf(&b, "Páginas:    %d\n", p.Pages)
+	}
+	if p.Job.Collapse {
+		fmt.Fprintf(&b, "Overlays:   %d → %d páginas\n", p.Pages, p.Printed)
+	}
+	if p.Mode.Booklet {
+		signature := "un solo cuadernillo"
+		if p.Job.Signature > 0 {
+			signature = fmt.Sprintf("cuadernillos de %d páginas", p.Job.Signature)
+		}
+		fmt.Fprintf(&b, "Folleto:    %s, 2 páginas por cara\n", signature)
+	}
+	if p.Job.imposed() {
+		border := "sin bordes"
+		if p.Job.Border {
+			border = "con bordes"
+		}
+		fmt.Fprintf(&b, "Por cara:   %d páginas, %s\n", p.Job.NUp, border)
+	}
+	if p.Job.Copies > 1 {
+		collate := "sin intercalar"
+		if p.Job.Collate {
+			collate = "intercaladas"
+		}
+		fmt.Fprintf(&b, "Copias:     %d, %s\n", p.Job.Copies, collate)
+	}
+	fmt.Fprintf(&b, "Papel:      %d hojas\n", p.Sheets)
+	fmt.Fprintf(&b, "En anakena: %s, en una carpeta temporal que se borra al terminar\n", strings.Join(p.Files, ", "))
+	fmt.Fprintf(&b, "Comando:\n  %s\n", p.Command)
+	return b.String()
+}
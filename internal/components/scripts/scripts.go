@@ -1,50 +1,83 @@
 package scripts
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
-	"github.com/atotto/clipboard"
+	"github.com/fgonzalezurriola/dccprint/internal/clipboard"
 	"github.com/fgonzalezurriola/dccprint/internal/config"
+	"github.com/fgonzalezurriola/dccprint/internal/pdf"
+	"github.com/fgonzalezurriola/dccprint/internal/queue"
+	"github.com/fgonzalezurriola/dccprint/internal/remote"
+	"github.com/fgonzalezurriola/dccprint/internal/shell"
 )
 
-// Func to retrieve all pdfs in the current dir
-func GetPDFFiles() []string {
-	var PDFs []string
+// Entry is an item of the file browser, a directory or a printable file
+type Entry struct {
+	Name string
+	Dir  bool
+}
 
-	currentDir, err := os.Getwd()
-	if err != nil {
-		log.Fatal(err)
-	}
-	entries, err := os.ReadDir(currentDir)
+// ListDir returns the visible subdirectories and printable files of dir, directories first
+func ListDir(dir string) ([]Entry, error) {
+	items, err := os.ReadDir(dir)
 	if err != nil {
-		log.Fatal(err)
+		return nil, &DirError{Dir: dir, Err: err}
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".pdf") {
-			PDFs = append(PDFs, entry.Name())
+	var dirs, files []Entry
+	for _, item := range items {
+		name := item.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		isDir := item.IsDir()
+		if item.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		switch {
+		case isDir:
+			dirs = append(dirs, Entry{Name: name, Dir: true})
+		case Supported(name):
+			files = append(files, Entry{Name: name})
 		}
 	}
-
-	return PDFs
+	return append(dirs, files...), nil
 }
 
-// ValidatePDFWithGhostscript validates a PDF file using Ghostscript with a 3-second timeout.
-// It uses fast flags (-o /dev/null -sDEVICE=nullpage) to avoid disk IO and speed up validation.
-// Returns nil if the file is valid or if timeout is reached.
-// Returns an error if Ghostscript detects a fatal error in the file.
-func ValidatePDFWithGhostscript(pdfPath string) error {
-	if _, err := exec.LookPath("gs"); err != nil {
-		return fmt.Errorf("Ghostscript (gs) is not installed: %w", err)
+// ValidatePDF checks the structure of the pdf in Go and, when Ghostscript is
+// installed, also renders it with gs for a deeper check. An encrypted
+// document the Go reader can't read is left to gs, pdf2ps decrypts it in
+// anakena. gs is stopped as soon as ctx is done.
+func ValidatePDF(ctx context.Context, pdfPath string) error {
+	if _, err := pdf.Validate(pdfPath); err != nil && !pdf.Encrypted(pdfPath) {
+		return &InvalidPDFError{File: pdfPath, Err: err}
+	}
+	if hasGhostscript() {
+		return validateWithGhostscript(ctx, pdfPath)
 	}
+	return nil
+}
 
+// validateWithGhostscript renders the pdf with gs, using fast flags
+// (-o /dev/null -sDEVICE=nullpage) to avoid disk IO. It returns nil if the
+// file is valid or gs takes more than 3 seconds.
+func validateWithGhostscript(ctx context.Context, pdfPath string) error {
+	// A relative name starting with - would be read as an option
+	if abs, err := filepath.Abs(pdfPath); err == nil {
+		pdfPath = abs
+	}
 	cmd := exec.Command("gs", "-o", "/dev/null", "-sDEVICE=nullpage", pdfPath)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 	var output strings.Builder
@@ -62,7 +95,7 @@ func ValidatePDFWithGhostscript(pdfPath string) error {
 		outStr := output.String()
 		if err != nil {
 			if strings.Contains(outStr, "Error") || strings.Contains(outStr, "FATAL") || strings.Contains(outStr, "Unrecoverable error") {
-				return fmt.Errorf("Ghostscript detected fatal error in PDF file. Output: %s", outStr)
+				return &InvalidPDFError{File: pdfPath, Err: fmt.Errorf("Ghostscript detected fatal error in PDF file. Output: %s", outStr)}
 			}
 			return nil
 		}
@@ -70,7 +103,52 @@ func ValidatePDFWithGhostscript(pdfPath string) error {
 	case <-time.After(3 * time.Second):
 		killProcessGroup(cmd)
 		return nil
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		return ctx.Err()
+	}
+}
+
+func hasGhostscript() bool {
+	_, err := exec.LookPath("gs")
+	return err == nil
+}
+
+var pageObjectRe = regexp.MustCompile(`/Type\s*/Page[^s]`)
+
+// CountPages returns the number of pages of a file once converted to pdf.
+// The page tree is read first, then Ghostscript is asked and as a last
+// resort the page objects are counted directly.
+func CountPages(path string) (int, error) {
+	pdfPath, cleanup, err := ConvertToPDF(path)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+
+	if n, err := pdf.Validate(pdfPath); err == nil {
+		return n, nil
+	}
+	if hasGhostscript() {
+		if abs, err := filepath.Abs(pdfPath); err == nil {
+			pdfPath = abs
+		}
+		escaped := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(pdfPath)
+		program := fmt.Sprintf("(%s) (r) file runpdfbegin pdfpagecount = quit", escaped)
+		// The file may come from anywhere, gs keeps its sandbox and may only read it
+		out, err := exec.Command("gs", "-q", "-dNODISPLAY", "-dSAFER", "--permit-file-read="+pdfPath, "-c", program).Output()
+		if err == nil {
+			if n, err := strconv.Atoi(strings.TrimSpace(string(out))); err == nil {
+				return n, nil
+			}
+		}
+	}
+
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return 0, fmt.Errorf("error leyendo %s: %w", pdfPath, err)
 	}
+	return len(pageObjectRe.FindAll(data, -1)), nil
 }
 
 // killProcessGroup forcefully kills the process group for the given command.
@@ -90,17 +168,425 @@ func killProcessGroup(cmd *exec.Cmd) {
 	time.Sleep(200 * time.Millisecond)
 }
 
-// Func to create the main feature in order to print
-func CreateScript(filename string) (string, error) {
-	originalEscapedName := EscapeFilename(filename)
-	basename := strings.TrimSuffix(originalEscapedName, filepath.Ext(originalEscapedName))
+// Job holds the settings used to print a single file
+type Job struct {
+	File    string
+	Account string
+	Printer string
+	Mode    string
+	Pages   PageRange
+	// NUp is the number of pages on each side of the sheet, 0 or 1 for one
+	NUp int
+	// Border draws a line around each page when NUp places several
+	Border bool
+	// Collapse keeps only the last page of each Beamer frame
+	Collapse bool
+	// Copies is the number of copies printed, 0 or 1 for one
+	Copies int
+	// Collate prints the copies one after the other instead of repeating each page
+	Collate bool
+	// Signature is the pages of each booklet signature, 0 for a single one
+	Signature int
+}
+
+// MaxCopies is the most copies a single job can ask for
+const MaxCopies = 99
+
+// NewJob builds a job for filename using the saved config as defaults
+func NewJob(filename string, cfg config.Config) Job {
+	return Job{
+		File:      filename,
+		Account:   cfg.Account,
+		Printer:   cfg.Printer,
+		Mode:      cfg.Mode,
+		NUp:       cfg.NUp,
+		Border:    cfg.NUpBorder,
+		Copies:    cfg.Copies,
+		Collate:   cfg.Collate,
+		Signature: cfg.Signature,
+	}
+}
+
+// resolve looks up the job's printer and mode in the registry
+func (job Job) resolve() (config.Printer, config.Mode, error) {
+	return config.LoadRegistry().Resolve(job.Printer, job.Mode)
+}
+
+// Duplex reports whether the job prints on both sides of the sheet
+func (job Job) Duplex() bool {
+	_, mode, err := job.resolve()
+	return err == nil && mode.Duplex
+}
+
+// Sheets estimates the paper used to print a document of total pages,
+// without counting the overlays Collapse would remove
+func (job Job) Sheets(total int) int {
+	return job.sheets(job.Pages.Count(total))
+}
+
+// Booklet reports whether the job's mode folds the sheets into a booklet
+func (job Job) Booklet() bool {
+	_, mode, err := job.resolve()
+	return err == nil && mode.Booklet
+}
+
+// sheets returns the paper used to print pages already selected
+func (job Job) sheets(pages int) int {
+	if job.Booklet() {
+		return pdf.BookletSheets(pages, job.Signature) * max(job.Copies, 1)
+	}
+	sides := pages
+	if job.imposed() {
+		sides = (sides + job.NUp - 1) / job.NUp
+	}
+	if job.Duplex() {
+		sides = (sides + 1) / 2
+	}
+	return sides * max(job.Copies, 1)
+}
 
-	cfg := config.Load()
-	username := cfg.Account
-	printer := cfg.Printer
-	mode := cfg.Mode
+// collated reports whether the copies are laid one after the other in the
+// uploaded pdf. lpr has no portable option to collate, -o collate is CUPS only.
+func (job Job) collated() bool {
+	return job.Copies > 1 && job.Collate
+}
 
-	if err := ValidatePDFWithGhostscript(filename); err != nil {
+// copiesFlags are the lpr options that print several copies, empty for one
+// or when the pdf already holds them
+func (job Job) copiesFlags() string {
+	if job.Copies <= 1 || job.collated() {
+		return ""
+	}
+	return fmt.Sprintf(" -#%d", job.Copies)
+}
+
+// EstimateSheets counts the pages of the job's file and returns the paper it
+// needs. Overlays are looked for only when the job removes them.
+func (job Job) EstimateSheets() (int, error) {
+	if !job.Collapse {
+		total, err := CountPages(job.File)
+		if err != nil {
+			return 0, err
+		}
+		return job.Sheets(total), nil
+	}
+	data, err := RenderPDF(job.File)
+	if err != nil {
+		return 0, err
+	}
+	_, layout, err := job.transform(data)
+	if err != nil {
+		return 0, err
+	}
+	return job.sheets(layout.After), nil
+}
+
+// imposed reports whether several pages are placed on each side before
+// uploading. Booklets always take two and ignore NUp.
+func (job Job) imposed() bool {
+	return job.NUp > 1 && !job.Booklet()
+}
+
+// local reports whether the pdf is changed before uploading. The page
+// range is then applied along with the changes.
+func (job Job) local() bool {
+	return job.imposed() || job.Collapse || job.Booklet() || job.collated()
+}
+
+// remotePages is the range psselect keeps in anakena, nil when the pages
+// were already picked before uploading
+func (job Job) remotePages() PageRange {
+	if job.local() {
+		return nil
+	}
+	return job.Pages
+}
+
+// Layout tells how the local changes left the pages of a job
+type Layout struct {
+	// Before and After are the pages kept by the range, before and after
+	// removing the overlays
+	Before, After int
+}
+
+// transform picks the pages of the pdf in data, removes the overlays,
+// places the pages NUp per side and repeats the collated copies, as the job asks
+func (job Job) transform(data []byte) ([]byte, Layout, error) {
+	name := filepath.Base(job.File)
+	total, err := pdf.ValidateData(data)
+	if err != nil {
+		return nil, Layout{}, &InvalidPDFError{File: name, Err: err}
+	}
+	pages := job.Pages.Pages(total)
+	layout := Layout{Before: job.Pages.Count(total)}
+	layout.After = layout.Before
+
+	if job.Collapse {
+		if data, layout.After, err = pdf.CollapseOverlays(data, pages); err != nil {
+			return nil, Layout{}, fmt.Errorf("no se pudieron quitar los overlays de %s: %w", name, err)
+		}
+		pages = nil
+	}
+	if job.Booklet() {
+		if data, err = pdf.Booklet(data, pages, job.Signature); err != nil {
+			return nil, Layout{}, fmt.Errorf("no se pudo armar el folleto de %s: %w", name, err)
+		}
+		pages = nil
+	} else if job.imposed() {
+		if data, err = pdf.NUp(data, pages, job.NUp, job.Border); err != nil {
+			return nil, Layout{}, fmt.Errorf("no se pudieron poner %d páginas por cara en %s: %w", job.NUp, name, err)
+		}
+		pages = nil
+	}
+	if job.collated() {
+		if data, err = pdf.Collate(data, pages, job.Copies, job.Duplex()); err != nil {
+			return nil, Layout{}, fmt.Errorf("no se pudieron intercalar las copias de %s: %w", name, err)
+		}
+	}
+	return data, layout, nil
+}
+
+// PreparePDF returns the pdf uploaded for job: its file converted to pdf and,
+// when it removes overlays, prints several pages per side or collates the
+// copies, with the pages already laid out. Temporary files are removed by cleanup.
+func (job Job) PreparePDF() (path string, layout Layout, cleanup func(), err error) {
+	path, cleanup, err = ConvertToPDF(job.File)
+	if err != nil || !job.local() {
+		return path, Layout{}, cleanup, err
+	}
+	defer cleanup()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", Layout{}, nil, fmt.Errorf("error leyendo %s: %w", path, err)
+	}
+	out, layout, err := job.transform(data)
+	if err != nil {
+		return "", Layout{}, nil, err
+	}
+	path, cleanup, err = writeTempPDF(out)
+	return path, layout, cleanup, err
+}
+
+// String describes the overlays removed, like "48 → 20 páginas sin overlays"
+func (l Layout) String() string {
+	return fmt.Sprintf("%d → %d páginas sin overlays", l.Before, l.After)
+}
+
+// basename returns the escaped name of the job's file without extension
+func (job Job) basename() string {
+	escaped := EscapeFilename(filepath.Base(job.File))
+	return strings.TrimSuffix(escaped, filepath.Ext(escaped))
+}
+
+// workspace creates a private directory for the job in anakena and moves
+// into it. The traps remove it when the shell exits, whether the job
+// worked, failed or the connection dropped.
+const workspace = `dir=$(mktemp -d "${TMPDIR:-/tmp}/dccprint.XXXXXX") || exit 1; ` +
+	`trap 'rm -rf "$dir"' EXIT; trap 'exit 1' HUP INT TERM PIPE; cd "$dir"`
+
+// RemoteCommand returns the command run in anakena. It reads the pdf from
+// stdin into a temporary directory, prints it and shows the queue. The
+// script is handed to sh, the login shell of the account may not be POSIX.
+func (job Job) RemoteCommand() (string, error) {
+	script, err := job.remoteScript()
+	if err != nil {
+		return "", err
+	}
+	return shell.Join("sh", "-c", script), nil
+}
+
+// remoteScript is the sh script run by RemoteCommand
+func (job Job) remoteScript() (string, error) {
+	pipeline, err := job.remotePipeline()
+	if err != nil {
+		return "", err
+	}
+	return workspace + " && " + pipeline, nil
+}
+
+// remoteFiles returns the names of the files the job writes in its remote
+// directory: the uploaded pdf, its PostScript and the selected pages, if any
+func (job Job) remoteFiles() []string {
+	title := job.title()
+	files := []string{title + ".pdf", title + ".ps"}
+	if job.remotePages() != nil {
+		files = append(files, title+"-sel.ps")
+	}
+	return files
+}
+
+// title names the job in the printer queue, whatever file reaches lpr
+func (job Job) title() string {
+	return "dccprint-" + job.basename()
+}
+
+// remotePipeline uploads the pdf from stdin and prints it, run inside the
+// job's directory
+func (job Job) remotePipeline() (string, error) {
+	printer, mode, err := job.resolve()
+	if err != nil {
+		return "", err
+	}
+
+	files := job.remoteFiles()
+	pdfname, psname := files[0], files[1]
+
+	// Only the selected pages are kept before sending to the printer
+	convert := shell.Join("pdf2ps", pdfname, psname)
+	printable := psname
+	if pages := job.remotePages(); pages != nil {
+		selname := files[2]
+		convert += " && " + shell.Join("psselect", "-q", "-p"+pages.String(), psname, selname)
+		printable = selname
+	}
+	printable = shell.Quote(printable)
+
+	lpr := printer.PrintCommand() + " -J " + shell.Quote(job.title()) + job.copiesFlags()
+	printCommand := fmt.Sprintf("%s %s", lpr, printable)
+	if mode.Filter != "" {
+		printCommand = fmt.Sprintf("%s %s|%s", mode.Filter, printable, lpr)
+	}
+
+	upload := "cat > " + shell.Quote(pdfname)
+	return fmt.Sprintf("%s && %s && %s && %s", upload, convert, printCommand, printer.ListCommand()), nil
+}
+
+// uploadReader tells when the whole pdf was read, that is, uploaded
+type uploadReader struct {
+	io.Reader
+	done func()
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF && r.done != nil {
+		r.done()
+		r.done = nil
+	}
+	return n, err
+}
+
+// JobStatus is the progress of a job sent over SSH
+type JobStatus int
+
+const (
+	StatusPending JobStatus = iota
+	StatusValidated
+	StatusUploaded
+	StatusQueued
+	StatusFailed
+)
+
+func (s JobStatus) String() string {
+	switch s {
+	case StatusValidated:
+		return "validado"
+	case StatusUploaded:
+		return "subido"
+	case StatusQueued:
+		return "en cola"
+	case StatusFailed:
+		return "falló"
+	}
+	return "pendiente"
+}
+
+// SendJob validates the job's file and runs the print pipeline through client,
+// streaming the pdf over the SSH session. Remote output is copied to out and
+// progress, when not nil, is told every step the job completes. The outcome is
+// recorded in the local history. It returns the id of the job in the printer
+// queue, 0 when it can't be found.
+func SendJob(client *remote.Client, job Job, out io.Writer, progress func(JobStatus)) (int, error) {
+	if progress == nil {
+		progress = func(JobStatus) {}
+	}
+	// The listing printed after lpr tells the id of the job
+	var output strings.Builder
+	err := sendJob(client, job, io.MultiWriter(out, &output), progress)
+	if err != nil {
+		progress(StatusFailed)
+	}
+	job.Record(err)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := queue.SubmittedID(output.String(), job.Account, job.title())
+	return id, nil
+}
+
+func sendJob(client *remote.Client, job Job, out io.Writer, progress func(JobStatus)) error {
+	pdfPath, layout, cleanup, err := job.PreparePDF()
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if job.Collapse {
+		fmt.Fprintf(out, "%s: %s\n", filepath.Base(job.File), layout)
+	}
+
+	if err := ValidatePDF(context.Background(), pdfPath); err != nil {
+		return err
+	}
+	progress(StatusValidated)
+
+	command, err := job.RemoteCommand()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(pdfPath)
+	if err != nil {
+		return fmt.Errorf("error abriendo %s: %w", pdfPath, err)
+	}
+	defer file.Close()
+
+	// The pdf is uploaded and printed in the same session, so its directory
+	// lives as long as the command
+	uploaded := false
+	stdin := &uploadReader{Reader: file, done: func() {
+		uploaded = true
+		progress(StatusUploaded)
+	}}
+	if err := client.Run(command, stdin, out); err != nil {
+		if !uploaded {
+			return fmt.Errorf("falló el envío a %s: %w", client.Host(), err)
+		}
+		return fmt.Errorf("falló la impresión en %s: %w", client.Host(), err)
+	}
+	progress(StatusQueued)
+	return nil
+}
+
+// CreateJobScript writes the self-deleting print script for job and returns its path
+func CreateJobScript(job Job) (string, error) {
+	basename := job.basename()
+	username := job.Account
+	// The account goes in the script and the ssh command line
+	if err := config.ValidateAccount(username); err != nil {
+		return "", err
+	}
+	settings := config.Load().SSH()
+
+	// A converted or imposed file is left in the temp dir and removed by the
+	// script, or here when the script can't be written
+	filename, layout, cleanup, err := job.PreparePDF()
+	if err != nil {
+		return "", err
+	}
+	written := false
+	defer func() {
+		if !written {
+			cleanup()
+		}
+	}()
+
+	if err := ValidatePDF(context.Background(), filename); err != nil {
+		return "", err
+	}
+
+	command, err := job.RemoteCommand()
+	if err != nil {
 		return "", err
 	}
 
@@ -110,6 +596,14 @@ GREEN='\033[0;32m'
 RED='\033[0;31m'
 NC='\033[0m'
 
+# dccprint sets DCCPRINT_TUI when it runs the script, so the output stays
+# on screen until the user goes back
+pause() {
+  if [ -n "$DCCPRINT_TUI" ]; then
+    read -r -p "Presiona Enter para volver a dccprint..." _
+  fi
+}
+
 echo -e "${ORANGE}"
 echo '
   ██████╗   ██████╗  ██████╗ ██████╗  ██████╗  ██╗ ███╗   ██╗ ████████╗
@@ -129,57 +623,27 @@ echo '2. Transfiere el archivo PDF con cat y ejecuta el comando de impresión'
 echo '==============================================================='
 
 `
-	pdfname := "dccprint-" + basename + ".pdf"
-	psname := "dccprint-" + basename + ".ps"
-
-	var printCommand string
-	switch printer {
-	case "Toqui":
-		switch mode {
-		case "Simple (Reverso en blanco)":
-			printCommand = fmt.Sprintf("pdf2ps %s %s && lpr %s", pdfname, psname, psname)
-		case "Doble cara, Borde largo (Recomendado)":
-			printCommand = fmt.Sprintf("pdf2ps %s %s && duplex %s|lpr", pdfname, psname, psname)
-		case "Doble cara, Borde corto":
-			printCommand = fmt.Sprintf("pdf2ps %s %s && duplex -l %s|lpr", pdfname, psname, psname)
-		}
-	case "Salita":
-		switch mode {
-		case "Simple (Reverso en blanco)":
-			printCommand = fmt.Sprintf("pdf2ps %s %s && lpr -P hp-335 %s", pdfname, psname, psname)
-		case "Doble cara, Borde largo (Recomendado)":
-			printCommand = fmt.Sprintf("pdf2ps %s %s && duplex %s|lpr -P hp-335", pdfname, psname, psname)
-		case "Doble cara, Borde corto":
-			printCommand = fmt.Sprintf("pdf2ps %s %s && duplex -l %s|lpr -P hp-335", pdfname, psname, psname)
-		}
-	}
-
-	var queueCommand string
-	switch printer {
-	case "Salita":
-		queueCommand = "lpq -P hp-335"
-	case "Toqui":
-		queueCommand = "lpq"
-	}
 
 	// SSH + cat sandwich to avoid asking two times the password
+	if job.Collapse {
+		scriptContent += fmt.Sprintf("echo '%s'\n", layout)
+	}
 	scriptContent += "echo -e \"${GREEN}Conectando a anakena y procesando archivo...${NC}\"\n"
-	// scriptContent += fmt.Sprintf("cat %q | ssh %s@anakena.dcc.uchile.cl 'cat > %s && %s && %s'\n",
-	// 	filename, username, pdfname, printCommand, queueCommand)
-
-	// Todo: test this to avoid trash in anakena
-	scriptContent += fmt.Sprintf("cat %q | ssh %s@anakena.dcc.uchile.cl 'cat > %s && %s && %s && rm %s %s'\n",
-		filename, username, pdfname, printCommand, queueCommand, pdfname, psname)
+	scriptContent += sshLine(settings, filename, username, command) + "\n"
 
 	scriptContent += "if [ $? -ne 0 ]; then\n"
 	scriptContent += "  echo -e \"${RED}ERROR: Falló la conexión o ejecución de comandos en anakena.${NC}\"\n"
-	scriptContent += "  exit 1\nfi\n\n"
+	scriptContent += "  pause\n  exit 1\nfi\n\n"
 
 	scriptContent += "echo -e \"${GREEN}¡IMPRESIÓN COMPLETADA!${NC}\"\n"
-	scriptContent += fmt.Sprintf("echo -e \"Recuerda: usa 'ssh %s@anakena.dcc.uchile.cl' y el comando 'papel' para ver impresiones restantes.\"\n", username)
+	scriptContent += reminderLine(settings, username) + "\n"
 	scriptContent += "echo -e \"Nota: El comando papel se actualiza después de haber finalizado la impresión\"\n"
+	scriptContent += "pause\n"
 
 	scriptPath := "dccprint-" + basename + ".sh"
+	if filename != job.File {
+		scriptContent += shell.Join("rm", "-f", "--", filename) + "\n"
+	}
 	// selfdestruction of script after use
 	scriptContent += `rm -- "$0"`
 
@@ -188,13 +652,33 @@ echo '==============================================================='
 		return "", fmt.Errorf("error escribiendo archivo: %w", writeErr)
 	}
 
+	written = true
 	return scriptPath, nil
 }
 
-func CopyToClipboard(text string) error {
-	importedErr := clipboard.WriteAll(text)
-	if importedErr != nil {
-		return fmt.Errorf("error copiando al portapapeles: %w", importedErr)
+// sshLine is the command of the script that uploads filename and runs command
+// in anakena. Every word is quoted, the remote shell gets command as is.
+func sshLine(settings remote.Settings, filename, username, command string) string {
+	return shell.Join("cat", "--", filename) + " | " + shell.Join(append(settings.Command(username), command)...)
+}
+
+// reminderLine prints the ssh command to check the paper left. The settings
+// go quoted, the identity file can hold any character.
+func reminderLine(settings remote.Settings, username string) string {
+	text := "Recuerda: usa '" + shell.Join(settings.Command(username)...) + "' y el comando 'papel' para ver impresiones restantes."
+	return "printf '%s\\n' " + shell.Quote(text)
+}
+
+// ClipboardBackend returns the clipboard set in the config, or the one
+// that works in this session when it is auto
+func ClipboardBackend() clipboard.Backend {
+	backend, err := clipboard.New(config.Load().Clipboard)
+	if err != nil {
+		return clipboard.Detect()
 	}
-	return nil
+	return backend
+}
+
+func CopyToClipboard(text string) error {
+	return ClipboardBackend().Copy(text)
 }
@@ -1,7 +1,19 @@
 package scripts
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/fgonzalezurriola/dccprint/internal/config"
+	"github.com/fgonzalezurriola/dccprint/internal/remote"
+	"github.com/fgonzalezurriola/dccprint/internal/shell"
 )
 
 func TestEscapeFilename(t *testing.T) {
@@ -31,3 +43,406 @@ func TestCopyToClipboard(t *testing.T) {
 		t.Errorf("CopyToClipboard devolvió error: %v", err)
 	}
 }
+
+func TestParsePageRange(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+		count    int
+	}{
+		{"", "", 20},
+		{"1-5,8,10-", "1-5,8,10-", 17},
+		{" 3 ", "3", 1},
+		{"-4", "1-4", 4},
+		{"15-30", "15-30", 6},
+		{"25", "25", 0},
+		// Overlapping spans print each page once
+		{"1-5,3-4", "1-5,3-4", 5},
+		{"10-,12-15", "10-,12-15", 11},
+	}
+	for _, c := range cases {
+		r, err := ParsePageRange(c.input)
+		if err != nil {
+			t.Errorf("ParsePageRange(%q) devolvió error: %v", c.input, err)
+			continue
+		}
+		if r.String() != c.expected {
+			t.Errorf("ParsePageRange(%q) = %q; want %q", c.input, r.String(), c.expected)
+		}
+		if got := r.Count(20); got != c.count {
+			t.Errorf("ParsePageRange(%q).Count(20) = %d; want %d", c.input, got, c.count)
+		}
+	}
+
+	for _, input := range []string{"0", "5-2", "a", "1,,2", "-", "1-2-3"} {
+		if _, err := ParsePageRange(input); err == nil {
+			t.Errorf("ParsePageRange(%q) debería fallar", input)
+		}
+	}
+}
+
+func TestRemoteCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	job := Job{File: "Tarea 1.pdf", Printer: "Salita", Mode: "largo"}
+	got, err := job.RemoteCommand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := workspace + " && cat > dccprint-tarea1.pdf && pdf2ps dccprint-tarea1.pdf dccprint-tarea1.ps && " +
+		"duplex dccprint-tarea1.ps|lpr -P hp-335 -J dccprint-tarea1 && lpq -P hp-335"
+	if got != "sh -c "+shell.Quote(want) {
+		t.Errorf("RemoteCommand() = %q; want sh -c %q", got, want)
+	}
+
+	job.Printer = "Impresora inexistente"
+	if _, err := job.RemoteCommand(); err == nil {
+		t.Errorf("RemoteCommand() debería fallar con una impresora desconocida")
+	}
+}
+
+// selects reports whether the remote script picks pages with psselect
+func selects(t *testing.T, job Job) bool {
+	t.Helper()
+	script, err := job.remoteScript()
+	if err != nil {
+		t.Fatalf("remoteScript(%+v) = %v", job, err)
+	}
+	return strings.Contains(script, "psselect")
+}
+
+func TestRemoteCommandNUp(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Pages placed several per side are selected before uploading
+	tests := []struct {
+		name     string
+		job      Job
+		total    int
+		psselect bool
+		sheets   int
+	}{
+		{"una por cara", Job{File: "Tarea 1.pdf", Printer: "Salita", Mode: "largo", Pages: PageRange{{First: 3, Last: 12}}}, 20, true, 5},
+		{"2 por cara", Job{File: "Tarea 1.pdf", Printer: "Salita", Mode: "largo", NUp: 2}, 18, false, 5},
+		{"4 por cara", Job{File: "Tarea 1.pdf", Printer: "Salita", Mode: "largo", Pages: PageRange{{First: 3, Last: 12}}, NUp: 4}, 20, false, 2},
+	}
+	for _, tt := range tests {
+		if got := selects(t, tt.job); got != tt.psselect {
+			t.Errorf("%s: psselect = %v; want %v", tt.name, got, tt.psselect)
+		}
+		if got := tt.job.Sheets(tt.total); got != tt.sheets {
+			t.Errorf("%s: Sheets(%d) = %d; want %d", tt.name, tt.total, got, tt.sheets)
+		}
+	}
+}
+
+func TestRemoteCommandCollapse(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Without overlays the pages are selected before uploading
+	tests := []struct {
+		job      Job
+		psselect bool
+	}{
+		{Job{File: "Clase 3.pdf", Printer: "Salita", Mode: "largo", Pages: PageRange{{First: 3, Last: 12}}}, true},
+		{Job{File: "Clase 3.pdf", Printer: "Salita", Mode: "largo", Pages: PageRange{{First: 3, Last: 12}}, Collapse: true}, false},
+		{Job{File: "Clase 3.pdf", Printer: "Salita", Mode: "largo", Collapse: true}, false},
+	}
+	for _, tt := range tests {
+		if got := selects(t, tt.job); got != tt.psselect {
+			t.Errorf("Pages %s, Collapse %v: psselect = %v; want %v", tt.job.Pages, tt.job.Collapse, got, tt.psselect)
+		}
+	}
+}
+
+func TestRemoteCommandCopies(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tests := []struct {
+		job    Job
+		lpr    string
+		sheets int
+	}{
+		{Job{File: "Tarea 1.pdf", Printer: "Salita", Mode: "largo"}, "|lpr -P hp-335 -J dccprint-tarea1 &&", 3},
+		{Job{File: "Tarea 1.pdf", Printer: "Salita", Mode: "largo", Copies: 3}, "|lpr -P hp-335 -J dccprint-tarea1 -#3 &&", 9},
+		// Collated copies are already in the uploaded pdf
+		{Job{File: "Tarea 1.pdf", Printer: "Salita", Mode: "largo", Copies: 3, Collate: true}, "|lpr -P hp-335 -J dccprint-tarea1 &&", 9},
+		{Job{File: "Tarea 1.pdf", Printer: "Salita", Mode: "simple", Copies: 2, Collate: true, Pages: PageRange{{First: 2, Last: 3}}}, "lpr -P hp-335 -J dccprint-tarea1 dccprint-tarea1.ps &&", 4},
+	}
+	for _, tt := range tests {
+		script, err := tt.job.remoteScript()
+		if err != nil || !strings.Contains(script, tt.lpr) {
+			t.Errorf("%d copias, Collate %v: remoteScript() = %q, %v; want %q", tt.job.Copies, tt.job.Collate, script, err, tt.lpr)
+		}
+		if tt.job.Collate && tt.job.remotePages() != nil {
+			t.Errorf("%d copias, Collate %v: psselect con copias intercaladas", tt.job.Copies, tt.job.Collate)
+		}
+		if got := tt.job.Sheets(5); got != tt.sheets {
+			t.Errorf("%d copias, Collate %v: Sheets(5) = %d; want %d", tt.job.Copies, tt.job.Collate, got, tt.sheets)
+		}
+	}
+}
+
+func TestRemoteCommandBooklet(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Booklets are laid out before uploading, 4 pages on each sheet
+	tests := []struct {
+		job    Job
+		total  int
+		sheets int
+	}{
+		{Job{File: "Apunte.pdf", Printer: "Salita", Mode: "folleto", Signature: 8, NUp: 4}, 18, 5},
+		{Job{File: "Apunte.pdf", Printer: "Salita", Mode: "folleto", NUp: 4}, 10, 3},
+		{Job{File: "Apunte.pdf", Printer: "Salita", Mode: "folleto", Signature: 8, NUp: 4, Copies: 2}, 18, 10},
+	}
+	for _, tt := range tests {
+		script, err := tt.job.remoteScript()
+		if err != nil || strings.Contains(script, "psselect") || !strings.Contains(script, "duplex -l dccprint-apunte.ps|lpr") {
+			t.Errorf("folleto de %d: remoteScript() = %q, %v", tt.job.Signature, script, err)
+		}
+		if got := tt.job.Sheets(tt.total); got != tt.sheets {
+			t.Errorf("folleto de %d: Sheets(%d) = %d; want %d", tt.job.Signature, tt.total, got, tt.sheets)
+		}
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for _, name := range []string{"apunte.pdf", "APUNTE.PDF", "main.go", "notas.md", "foto.JPG", "log.txt"} {
+		if !Supported(name) {
+			t.Errorf("Supported(%q) = false; want true", name)
+		}
+	}
+	for _, name := range []string{"tarea.docx", "Makefile", "pdf"} {
+		if Supported(name) {
+			t.Errorf("Supported(%q) = true; want false", name)
+		}
+	}
+}
+
+func TestConvertToPDF(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "notas.txt")
+	var text strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&text, "línea %d (con paréntesis) y ñandú\n", i)
+	}
+	if err := os.WriteFile(src, []byte(text.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, cleanup, err := ConvertToPDF(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Errorf("el archivo convertido no es un pdf")
+	}
+	if pages, err := CountPages(src); err != nil || pages != 2 {
+		t.Errorf("CountPages() = %d, %v; want 2 páginas", pages, err)
+	}
+}
+
+func TestValidatePDFInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "roto.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4\nno hay nada más"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var invalid *InvalidPDFError
+	if err := ValidatePDF(context.Background(), path); !errors.As(err, &invalid) {
+		t.Errorf("ValidatePDF() = %v; want *InvalidPDFError", err)
+	}
+	var dirErr *DirError
+	if _, err := ListDir(filepath.Join(t.TempDir(), "no-existe")); !errors.As(err, &dirErr) {
+		t.Errorf("ListDir() = %v; want *DirError", err)
+	}
+}
+
+// TestValidatePDFEncrypted checks that without Ghostscript an encrypted
+// document is printed even when the Go reader can't read its objects
+func TestValidatePDFEncrypted(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	// The page tree would be inside an encrypted object stream
+	objects := []string{
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n",
+		"3 0 obj\n<< /Filter /Standard /V 2 /R 3 /Length 128 /P -4 /O (x) /U (x) >>\nendobj\n",
+	}
+	var b strings.Builder
+	b.WriteString("%PDF-1.6\n")
+	var offsets []int
+	for _, obj := range objects {
+		offsets = append(offsets, b.Len())
+		b.WriteString(obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 4\n0000000000 65535 f \n%010d 00000 n \n0000000000 65535 f \n%010d 00000 n \n", offsets[0], offsets[1])
+	fmt.Fprintf(&b, "trailer\n<< /Size 4 /Root 1 0 R /Encrypt 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", xref)
+
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "cifrado.pdf")
+	if err := os.WriteFile(encrypted, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidatePDF(context.Background(), encrypted); err != nil {
+		t.Errorf("ValidatePDF() cifrado = %v; want nil", err)
+	}
+
+	plain := filepath.Join(dir, "roto.pdf")
+	data := strings.Replace(b.String(), " /Encrypt 3 0 R", "               ", 1)
+	if err := os.WriteFile(plain, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var invalid *InvalidPDFError
+	if err := ValidatePDF(context.Background(), plain); !errors.As(err, &invalid) {
+		t.Errorf("ValidatePDF() sin cifrar = %v; want *InvalidPDFError", err)
+	}
+}
+
+// TestRemoteWorkspace runs the remote command with the print tools faked and
+// checks that its directory is gone whether printing works or fails
+func TestRemoteWorkspace(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no hay sh")
+	}
+	t.Setenv("HOME", t.TempDir())
+	command, err := Job{File: "Tarea 1.pdf", Printer: "Salita", Mode: "largo"}.RemoteCommand()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The command starts its own sh, so the tools are faked as programs
+	tools := map[string]string{
+		"duplex": `cat "$1"`,
+		"lpr":    "cat > /dev/null",
+		"lpq":    ":",
+	}
+	for name, pdf2ps := range map[string]string{
+		"ok":    `cp "$1" "$2"`,
+		"falla": "exit 1",
+	} {
+		bin := t.TempDir()
+		tools["pdf2ps"] = pdf2ps
+		for tool, body := range tools {
+			if err := os.WriteFile(filepath.Join(bin, tool), []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tmp := t.TempDir()
+		cmd := exec.Command(sh, "-c", command)
+		cmd.Env = append(os.Environ(), "TMPDIR="+tmp, "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+		cmd.Stdin = strings.NewReader("%PDF-1.4")
+		out, err := cmd.CombinedOutput()
+		if (err == nil) != (name == "ok") {
+			t.Errorf("%s: sh = %v: %s", name, err, out)
+		}
+		if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+			t.Errorf("%s: quedó %s en el directorio temporal", name, entries[0].Name())
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	src := filepath.Join(dir, "notas.txt")
+	if err := os.WriteFile(src, []byte(strings.Repeat("línea\n", 150)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	job := Job{File: src, Account: "alumno", Printer: "Salita", Mode: "largo", Pages: PageRange{{First: 2}}}
+	plan, err := job.Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Total != 3 || plan.Pages != 2 || plan.Sheets != 1 {
+		t.Errorf("Explain() = %d de %d páginas en %d hojas; want 2 de 3 en 1", plan.Pages, plan.Total, plan.Sheets)
+	}
+	if plan.Printer.Queue != "hp-335" || len(plan.Files) != 3 {
+		t.Errorf("Explain() cola %q, archivos %v", plan.Printer.Queue, plan.Files)
+	}
+	if !strings.Contains(plan.Command, "| ssh alumno@") || !strings.Contains(plan.Command, "psselect -q -p2-") {
+		t.Errorf("Explain() comando = %q", plan.Command)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Explain() escribió %d archivos", len(entries)-1)
+	}
+}
+
+// TestReminderLine runs the reminder of the script with settings that would
+// break out of double quotes
+func TestReminderLine(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no hay sh")
+	}
+	dir := t.TempDir()
+	settings := remote.Settings{IdentityFile: "~/.ssh/a\"$(touch pwned)`touch pwned`'b", JumpHost: "gate"}
+	cmd := exec.Command(sh, "-c", reminderLine(settings, "alumno"))
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Recuerda: usa '" + shell.Join(settings.Command("alumno")...) + "' y el comando 'papel' para ver impresiones restantes.\n"
+	if string(out) != want {
+		t.Errorf("reminderLine() imprimió %q; want %q", out, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); err == nil {
+		t.Error("reminderLine() ejecutó un comando del identity file")
+	}
+}
+
+// FuzzSSHLine runs the line of the script with cat and ssh replaced by
+// functions that print their arguments, checking that neither the file
+// name nor an account that passes validation can add commands
+func FuzzSSHLine(f *testing.F) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		f.Skip("no hay sh")
+	}
+	f.Setenv("HOME", f.TempDir())
+	seeds := [][2]string{
+		{"Tarea 1.pdf", "alumno"},
+		{"$(touch pwned).pdf", "alumno"},
+		{"`id`'; rm -rf ~; '.pdf", "j.perez"},
+		{"-n.pdf", "-oProxyCommand=id"},
+		{"a\"b$HOME\\.pdf", "alumno;id"},
+		{"línea\nnueva.pdf", "user_2"},
+	}
+	for _, seed := range seeds {
+		f.Add(seed[0], seed[1])
+	}
+	f.Fuzz(func(t *testing.T, filename, account string) {
+		if strings.ContainsRune(filename, 0) || config.ValidateAccount(account) != nil {
+			t.Skip()
+		}
+		job := Job{File: filename, Account: account, Printer: "Salita", Mode: "largo"}
+		command, err := job.RemoteCommand()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range job.remoteFiles() {
+			if shell.Quote(name) != name {
+				t.Errorf("remoteFiles() = %q, necesita comillas", name)
+			}
+		}
+
+		script := "cat() { printf '%s\\0' \"$@\" >&2; }\nssh() { printf '%s\\0' \"$@\"; }\n" + sshLine(remote.Settings{}, filename, account, command)
+		cmd := exec.Command(sh, "-c", script)
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("sh: %v: %s", err, stderr.String())
+		}
+		if want := "--\x00" + filename + "\x00"; stderr.String() != want {
+			t.Errorf("cat recibió %q; want %q", stderr.String(), want)
+		}
+		if want := account + "@" + remote.DefaultHost + "\x00" + command + "\x00"; stdout.String() != want {
+			t.Errorf("ssh recibió %q; want %q", stdout.String(), want)
+		}
+	})
+}
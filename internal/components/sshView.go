@@ -0,0 +1,133 @@
+package components
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/fgonzalezurriola/dccprint/internal/remote"
+	"github.com/fgonzalezurriola/dccprint/internal/theme"
+)
+
+// sshFields are the labels of the inputs, in the order of the settings
+var sshFields = []string{"Servidor", "Puerto", "Llave", "Salto"}
+
+// SSHView edits how dccprint connects to anakena. Empty inputs fall back
+// to ~/.ssh/config and the defaults.
+type SSHView struct {
+	inputs []textinput.Model
+	focus  int
+	theme  *theme.Theme
+	width  int
+	height int
+}
+
+func NewSSHView(theme *theme.Theme) SSHView {
+	placeholders := []string{
+		remote.DefaultHost + " o un alias de ~/.ssh/config",
+		strconv.Itoa(remote.DefaultPort),
+		"~/.ssh/id_ed25519",
+		"usuario@servidor[:puerto], para conectarse desde fuera",
+	}
+	v := SSHView{theme: theme}
+	for _, placeholder := range placeholders {
+		ti := textinput.New()
+		ti.Placeholder = placeholder
+		ti.CharLimit = 256
+		v.inputs = append(v.inputs, ti)
+	}
+	v.inputs[1].CharLimit = 5
+	v.SetTheme(theme)
+	return v
+}
+
+// SetSettings fills the inputs with s and focuses the first one
+func (v *SSHView) SetSettings(s remote.Settings) {
+	port := ""
+	if s.Port != 0 {
+		port = strconv.Itoa(s.Port)
+	}
+	for i, value := range []string{s.Host, port, s.IdentityFile, s.JumpHost} {
+		v.inputs[i].SetValue(value)
+	}
+	v.setFocus(0)
+}
+
+// Settings returns the values typed, without checking them beyond the port
+func (v *SSHView) Settings() (remote.Settings, error) {
+	value := func(i int) string { return strings.TrimSpace(v.inputs[i].Value()) }
+	s := remote.Settings{Host: value(0), IdentityFile: value(2), JumpHost: value(3)}
+	if port := value(1); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return s, fmt.Errorf("puerto inválido: %s", port)
+		}
+		s.Port = n
+	}
+	return s, nil
+}
+
+func (v *SSHView) setFocus(i int) {
+	v.focus = i
+	for j := range v.inputs {
+		if j == i {
+			v.inputs[j].Focus()
+		} else {
+			v.inputs[j].Blur()
+		}
+	}
+}
+
+func (v SSHView) Init() tea.Cmd {
+	return nil
+}
+
+func (v SSHView) Update(msg tea.Msg) (SSHView, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "tab", "down":
+			v.setFocus((v.focus + 1) % len(v.inputs))
+			return v, nil
+		case "shift+tab", "up":
+			v.setFocus((v.focus + len(v.inputs) - 1) % len(v.inputs))
+			return v, nil
+		}
+	}
+	var cmd tea.Cmd
+	v.inputs[v.focus], cmd = v.inputs[v.focus].Update(msg)
+	return v, cmd
+}
+
+func (v SSHView) View() string {
+	labelStyle := lipgloss.NewStyle().Foreground(v.theme.Unselected).Width(10)
+	selectedStyle := labelStyle.Foreground(v.theme.Selected)
+	dimStyle := lipgloss.NewStyle().Foreground(v.theme.Unselected)
+
+	lines := []string{dimStyle.Render("Conexión con anakena, los campos vacíos usan ~/.ssh/config"), ""}
+	for i, input := range v.inputs {
+		style := labelStyle
+		if i == v.focus {
+			style = selectedStyle
+		}
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Left, style.Render(sshFields[i]), input.View()))
+	}
+	lines = append(lines, "", dimStyle.Render("tab: siguiente campo • enter: guardar • esc: volver"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func (v *SSHView) SetTheme(theme *theme.Theme) {
+	v.theme = theme
+	for i := range v.inputs {
+		v.inputs[i].PromptStyle = lipgloss.NewStyle().Foreground(theme.Selected)
+		v.inputs[i].TextStyle = lipgloss.NewStyle().Foreground(theme.Header)
+	}
+}
+
+func (v *SSHView) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+}
@@ -2,34 +2,52 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
-)
-
-type ConfigItem struct {
-	Name    string
-	Command string
-}
+	"regexp"
 
-var printers = map[int]ConfigItem{
-	1: {"Salita", "lpr -P hp-335"},
-	2: {"Toqui", "lpr"},
-}
-
-var modes = map[int]ConfigItem{
-	1: {"Simple", ""},
-	2: {"Doble", "duplex"},
-}
+	"github.com/fgonzalezurriola/dccprint/internal/quota"
+	"github.com/fgonzalezurriola/dccprint/internal/remote"
+)
 
-// Todo: support -dFirstPage= y -dLastPage= from postscript (or psselect -p5-10)
-// Todo: Consultar papel?
 type Config struct {
 	Theme   string `json:"theme"`
 	Account string `json:"account"`
 	Printer string `json:"printer"`
 	Mode    string `json:"mode"`
+	// Last paper quota read with papel, nil until the first query
+	Quota *quota.Quota `json:"quota,omitempty"`
+	// Directories where files were printed from, most recent first
+	RecentDirs []string `json:"recent_dirs,omitempty"`
+	// Clipboard backend: auto, native, osc52 or print. Empty means auto.
+	Clipboard string `json:"clipboard,omitempty"`
+	// Pages printed on each side of the sheet, 0 or 1 for one
+	NUp int `json:"nup,omitempty"`
+	// NUpBorder draws a line around each page when NUp is more than one
+	NUpBorder bool `json:"nup_border,omitempty"`
+	// Copies printed of each job, 0 or 1 for one
+	Copies int `json:"copies,omitempty"`
+	// Collate prints the copies one after the other
+	Collate bool `json:"collate,omitempty"`
+	// Pages of each booklet signature, 0 to fold the whole document at once
+	Signature int `json:"signature,omitempty"`
+	// SSH server, port, private key and ProxyJump hosts. Empty values come
+	// from ~/.ssh/config or the defaults.
+	SSHHost     string `json:"ssh_host,omitempty"`
+	SSHPort     int    `json:"ssh_port,omitempty"`
+	SSHIdentity string `json:"ssh_identity,omitempty"`
+	SSHJump     string `json:"ssh_jump,omitempty"`
 }
 
+// SSH returns how to connect to anakena
+func (c Config) SSH() remote.Settings {
+	return remote.Settings{Host: c.SSHHost, Port: c.SSHPort, IdentityFile: c.SSHIdentity, JumpHost: c.SSHJump}
+}
+
+// Number of directories kept in RecentDirs
+const maxRecentDirs = 5
+
 func configPath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -64,21 +82,38 @@ func Load() Config {
 	return cfg
 }
 
+// WriteError is returned when the configuration can't be saved
+type WriteError struct {
+	Path string
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("no se pudo guardar la configuración en %s: %v", e.Path, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
 func save(cfg Config) error {
 	path, err := configPath()
 	if err != nil {
-		return err
+		return &WriteError{Path: "~/.dccprint_config.json", Err: err}
 	}
 
 	file, err := os.Create(path)
 	if err != nil {
-		return err
+		return &WriteError{Path: path, Err: err}
 	}
 	defer file.Close()
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	return encoder.Encode(cfg)
+	if err := encoder.Encode(cfg); err != nil {
+		return &WriteError{Path: path, Err: err}
+	}
+	return nil
 }
 
 func updateConfig(updater func(cfg *Config)) error {
@@ -95,7 +130,37 @@ func SaveTheme(theme string) error {
 	return updateConfig(func(cfg *Config) { cfg.Theme = theme })
 }
 
+// accountRe matches the DCC usernames: a lowercase letter followed by
+// lowercase letters, numbers, dots, underscores or hyphens
+var accountRe = regexp.MustCompile(`^[a-z][a-z0-9._-]*$`)
+
+// MaxAccountLength is the longest username accepted
+const MaxAccountLength = 32
+
+// AccountError is returned for a name that can't be a DCC account
+type AccountError struct {
+	Account string
+}
+
+func (e *AccountError) Error() string {
+	if e.Account == "" {
+		return "falta el nombre de cuenta"
+	}
+	return fmt.Sprintf("cuenta inválida: %q (usa minúsculas, números, '.', '_' o '-', empezando con una letra)", e.Account)
+}
+
+// ValidateAccount checks that account follows the rules of DCC usernames
+func ValidateAccount(account string) error {
+	if len(account) > MaxAccountLength || !accountRe.MatchString(account) {
+		return &AccountError{Account: account}
+	}
+	return nil
+}
+
 func SaveAccount(account string) error {
+	if err := ValidateAccount(account); err != nil {
+		return err
+	}
 	return updateConfig(func(cfg *Config) { cfg.Account = account })
 }
 
@@ -106,3 +171,47 @@ func SavePrinter(printer string) error {
 func SaveMode(mode string) error {
 	return updateConfig(func(cfg *Config) { cfg.Mode = mode })
 }
+
+func SaveClipboard(backend string) error {
+	return updateConfig(func(cfg *Config) { cfg.Clipboard = backend })
+}
+
+func SaveLayout(nup int, border bool) error {
+	return updateConfig(func(cfg *Config) { cfg.NUp, cfg.NUpBorder = nup, border })
+}
+
+func SaveCopies(copies int, collate bool) error {
+	return updateConfig(func(cfg *Config) { cfg.Copies, cfg.Collate = copies, collate })
+}
+
+func SaveSignature(signature int) error {
+	return updateConfig(func(cfg *Config) { cfg.Signature = signature })
+}
+
+func SaveSSH(s remote.Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	return updateConfig(func(cfg *Config) {
+		cfg.SSHHost, cfg.SSHPort, cfg.SSHIdentity, cfg.SSHJump = s.Host, s.Port, s.IdentityFile, s.JumpHost
+	})
+}
+
+func SaveQuota(q quota.Quota) error {
+	return updateConfig(func(cfg *Config) { cfg.Quota = &q })
+}
+
+// SaveRecentDir moves dir to the front of the recent directories
+func SaveRecentDir(dir string) error {
+	return updateConfig(func(cfg *Config) { cfg.RecentDirs = pushRecentDir(cfg.RecentDirs, dir) })
+}
+
+func pushRecentDir(dirs []string, dir string) []string {
+	recent := []string{dir}
+	for _, d := range dirs {
+		if d != dir && len(recent) < maxRecentDirs {
+			recent = append(recent, d)
+		}
+	}
+	return recent
+}
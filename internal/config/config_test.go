@@ -0,0 +1,22 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateAccount(t *testing.T) {
+	for _, account := range []string{"alumno", "fgonzalez", "j.perez", "user_2", "a-b"} {
+		if err := ValidateAccount(account); err != nil {
+			t.Errorf("ValidateAccount(%q) = %v; want nil", account, err)
+		}
+	}
+	invalid := []string{"", "Alumno", "2alumno", "-oProxyCommand=id", "alumno@anakena", "al umno", "a;id", "$(id)", "`id`", "alumno'", strings.Repeat("a", 33)}
+	for _, account := range invalid {
+		var accountErr *AccountError
+		if err := ValidateAccount(account); !errors.As(err, &accountErr) {
+			t.Errorf("ValidateAccount(%q) = %v; want *AccountError", account, err)
+		}
+	}
+}
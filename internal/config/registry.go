@@ -0,0 +1,208 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fgonzalezurriola/dccprint/internal/shell"
+)
+
+// Printer is a DCC printer reachable from anakena
+type Printer struct {
+	Name string `json:"name"`
+	// Queue is the lpr queue, empty for the default one
+	Queue string `json:"queue"`
+	// Duplex is false for printers that can't take the duplex modes
+	Duplex bool `json:"duplex"`
+	// QueueCommand overrides the lpq command used to list the queue
+	QueueCommand string `json:"queue_command,omitempty"`
+}
+
+// Mode is a way of laying the pages on paper
+type Mode struct {
+	Name string `json:"name"`
+	// Alias is the short name accepted by the command line
+	Alias string `json:"alias"`
+	// Filter is the command run in anakena on the PostScript before lpr
+	Filter string `json:"filter,omitempty"`
+	Duplex bool   `json:"duplex"`
+	// Booklet reorders the pages two per side so the sheets fold into a booklet
+	Booklet bool `json:"booklet,omitempty"`
+}
+
+// Registry lists the printers and modes offered in the menus
+type Registry struct {
+	Printers []Printer `json:"printers"`
+	Modes    []Mode    `json:"modes"`
+}
+
+var defaultRegistry = Registry{
+	Printers: []Printer{
+		{Name: "Salita", Queue: "hp-335", Duplex: true},
+		{Name: "Toqui", Queue: "", Duplex: true},
+	},
+	Modes: []Mode{
+		{Name: "Doble cara, Borde largo (Recomendado)", Alias: "largo", Filter: "duplex", Duplex: true},
+		{Name: "Doble cara, Borde corto", Alias: "corto", Filter: "duplex -l", Duplex: true},
+		{Name: "Simple (Reverso en blanco)", Alias: "simple"},
+		{Name: "Folleto (Doble cara, para doblar)", Alias: "folleto", Filter: "duplex -l", Duplex: true, Booklet: true},
+	},
+}
+
+func registryPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".dccprint_printers.json"), nil
+}
+
+// LoadRegistry returns the built-in printers and modes merged with the ones
+// in ~/.dccprint_printers.json. Entries with the same name replace the built-in ones.
+func LoadRegistry() Registry {
+	registry := Registry{
+		Printers: append([]Printer(nil), defaultRegistry.Printers...),
+		Modes:    append([]Mode(nil), defaultRegistry.Modes...),
+	}
+
+	path, err := registryPath()
+	if err != nil {
+		return registry
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return registry
+	}
+	defer file.Close()
+
+	var custom Registry
+	if err := json.NewDecoder(file).Decode(&custom); err != nil {
+		return registry
+	}
+
+	for _, p := range custom.Printers {
+		if p.Name == "" {
+			continue
+		}
+		if i := registry.printerIndex(p.Name); i >= 0 {
+			registry.Printers[i] = p
+		} else {
+			registry.Printers = append(registry.Printers, p)
+		}
+	}
+	for _, m := range custom.Modes {
+		if m.Name == "" {
+			continue
+		}
+		if i := registry.modeIndex(m.Name); i >= 0 {
+			registry.Modes[i] = m
+		} else {
+			registry.Modes = append(registry.Modes, m)
+		}
+	}
+	return registry
+}
+
+func (r Registry) printerIndex(name string) int {
+	for i, p := range r.Printers {
+		if strings.EqualFold(p.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r Registry) modeIndex(name string) int {
+	for i, m := range r.Modes {
+		// Custom modes may have no alias, which must not match an empty name
+		if m.Name == name || (m.Alias != "" && strings.EqualFold(m.Alias, name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Printer finds a printer by name, ignoring case
+func (r Registry) Printer(name string) (Printer, error) {
+	if i := r.printerIndex(name); i >= 0 {
+		return r.Printers[i], nil
+	}
+	return Printer{}, fmt.Errorf("impresora desconocida: %s", name)
+}
+
+// Mode finds a mode by its menu label or its alias
+func (r Registry) Mode(name string) (Mode, error) {
+	if i := r.modeIndex(name); i >= 0 {
+		return r.Modes[i], nil
+	}
+	return Mode{}, fmt.Errorf("modo desconocido: %s", name)
+}
+
+// Resolve validates that mode can be printed on printer
+func (r Registry) Resolve(printerName, modeName string) (Printer, Mode, error) {
+	printer, err := r.Printer(printerName)
+	if err != nil {
+		return Printer{}, Mode{}, err
+	}
+	mode, err := r.Mode(modeName)
+	if err != nil {
+		return Printer{}, Mode{}, err
+	}
+	if mode.Duplex && !printer.Duplex {
+		return Printer{}, Mode{}, fmt.Errorf("%s no imprime en %s", printer.Name, mode.Name)
+	}
+	return printer, mode, nil
+}
+
+func (r Registry) PrinterNames() []string {
+	names := make([]string, len(r.Printers))
+	for i, p := range r.Printers {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func (r Registry) ModeNames() []string {
+	names := make([]string, len(r.Modes))
+	for i, m := range r.Modes {
+		names[i] = m.Name
+	}
+	return names
+}
+
+func (r Registry) ModeAliases() []string {
+	aliases := make([]string, len(r.Modes))
+	for i, m := range r.Modes {
+		aliases[i] = m.Alias
+	}
+	return aliases
+}
+
+// queueFlag returns the -P flag of lpr, lpq and lprm
+func (p Printer) queueFlag() string {
+	if p.Queue == "" {
+		return ""
+	}
+	return " -P " + shell.Quote(p.Queue)
+}
+
+// PrintCommand returns the lpr invocation, without the file
+func (p Printer) PrintCommand() string {
+	return "lpr" + p.queueFlag()
+}
+
+// ListCommand returns the lpq invocation that lists the queue
+func (p Printer) ListCommand() string {
+	if p.QueueCommand != "" {
+		return p.QueueCommand
+	}
+	return "lpq" + p.queueFlag()
+}
+
+// CancelCommand returns the lprm invocation that removes job id
+func (p Printer) CancelCommand(id int) string {
+	return fmt.Sprintf("lprm%s %d", p.queueFlag(), id)
+}
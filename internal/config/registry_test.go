@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRegistryCustomFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	custom := `{
+  "printers": [
+    {"name": "Toqui", "queue": "toqui", "duplex": false},
+    {"name": "Biblioteca", "queue": "hp-bib", "duplex": true, "queue_command": "lpq -a"}
+  ],
+  "modes": [
+    {"name": "Borrador", "filter": "duplex", "duplex": true}
+  ]
+}`
+	if err := os.WriteFile(filepath.Join(home, ".dccprint_printers.json"), []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := LoadRegistry()
+	if got := len(r.Printers); got != 3 {
+		t.Fatalf("len(Printers) = %d; want 3", got)
+	}
+
+	toqui, err := r.Printer("toqui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toqui.PrintCommand() != "lpr -P toqui" {
+		t.Errorf("PrintCommand() = %q", toqui.PrintCommand())
+	}
+	if _, _, err := r.Resolve("Toqui", "largo"); err == nil {
+		t.Errorf("Toqui no debería aceptar modos doble cara")
+	}
+
+	bib, err := r.Printer("Biblioteca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bib.ListCommand() != "lpq -a" || bib.CancelCommand(7) != "lprm -P hp-bib 7" {
+		t.Errorf("comandos inesperados: %q, %q", bib.ListCommand(), bib.CancelCommand(7))
+	}
+
+	if m, err := r.Mode("Borrador"); err != nil || m.Filter != "duplex" {
+		t.Errorf("Mode(Borrador) = %+v, %v", m, err)
+	}
+	if m, err := r.Mode(""); err == nil {
+		t.Errorf("Mode(\"\") = %+v; want an error", m)
+	}
+}
@@ -0,0 +1,163 @@
+package history
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OutcomeOK is stored for jobs that reached the printer queue
+const OutcomeOK = "ok"
+
+// Entry is a job sent to a printer, with the settings needed to send it again
+type Entry struct {
+	ID        int       `json:"id"`
+	Time      time.Time `json:"time"`
+	File      string    `json:"file"`
+	Hash      string    `json:"hash"`
+	Pages     int       `json:"pages"`
+	Printer   string    `json:"printer"`
+	Mode      string    `json:"mode"`
+	Account   string    `json:"account"`
+	Range     string    `json:"range,omitempty"`
+	NUp       int       `json:"nup,omitempty"`
+	Border    bool      `json:"border,omitempty"`
+	Collapse  bool      `json:"collapse,omitempty"`
+	Copies    int       `json:"copies,omitempty"`
+	Collate   bool      `json:"collate,omitempty"`
+	Signature int       `json:"signature,omitempty"`
+	Outcome   string    `json:"outcome"`
+}
+
+// Path returns the append-only history file, one JSON entry per line
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "dccprint", "history.jsonl"), nil
+}
+
+// Load returns every entry, oldest first. A missing file is an empty history.
+func Load() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e Entry
+		// A line cut by a crash shouldn't hide the rest of the history
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// Append assigns the next id to e and adds it at the end of the history
+func Append(e Entry) (Entry, error) {
+	entries, err := Load()
+	if err != nil {
+		return e, err
+	}
+	e.ID = 1
+	if len(entries) > 0 {
+		e.ID = entries[len(entries)-1].ID + 1
+	}
+
+	path, err := Path()
+	if err != nil {
+		return e, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return e, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return e, err
+	}
+	defer file.Close()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+	_, err = file.Write(append(line, '\n'))
+	return e, err
+}
+
+// Find returns the entry with the given id
+func Find(id int) (Entry, error) {
+	entries, err := Load()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Entry{}, fmt.Errorf("no existe el trabajo %d en el historial", id)
+}
+
+// HashFile returns the SHA-256 of a file in hex
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Changed reports whether the file no longer matches the printed content
+func (e Entry) Changed() bool {
+	hash, err := HashFile(e.File)
+	return err != nil || hash != e.Hash
+}
+
+// String summarizes the entry in one line
+func (e Entry) String() string {
+	pages := fmt.Sprintf("%d pág", e.Pages)
+	if e.Range != "" {
+		pages += " (" + e.Range + ")"
+	}
+	return fmt.Sprintf("#%-4d %s  %-28.28s %-16s %-8s %s", e.ID, e.Time.Format("02/01 15:04"),
+		filepath.Base(e.File), pages, e.Printer, firstLine(e.Outcome))
+}
+
+// firstLine keeps long remote errors from breaking the listing
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	// Cut by runes, the errors are in Spanish
+	if runes := []rune(s); len(runes) > 40 {
+		s = string(runes[:37]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestAppendAndFind(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	file := filepath.Join(t.TempDir(), "apunte.pdf")
+	if err := os.WriteFile(file, []byte("%PDF-1.4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := HashFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		e, err := Append(Entry{Time: time.Now(), File: file, Hash: hash, Pages: 10, Printer: "Salita", Outcome: OutcomeOK})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.ID != i+1 {
+			t.Errorf("Append() asignó id %d; want %d", e.ID, i+1)
+		}
+	}
+
+	e, err := Find(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.File != file || e.Changed() {
+		t.Errorf("Find(2) = %+v", e)
+	}
+
+	os.WriteFile(file, []byte("%PDF-1.5"), 0644)
+	if !e.Changed() {
+		t.Errorf("Changed() = false después de modificar el archivo")
+	}
+	if _, err := Find(9); err == nil {
+		t.Errorf("Find(9) debería fallar")
+	}
+}
+
+func TestFirstLine(t *testing.T) {
+	long := "falló la conexión: autenticación rechazada por anakena.dcc.uchile.cl"
+	got := firstLine(long + "\nsegunda línea")
+	if !utf8.ValidString(got) {
+		t.Errorf("firstLine() = %q, no es UTF-8 válido", got)
+	}
+	if want := string([]rune(long)[:37]) + "..."; got != want {
+		t.Errorf("firstLine() = %q; want %q", got, want)
+	}
+	if got := firstLine("impresión ñandú"); got != "impresión ñandú" {
+		t.Errorf("firstLine() = %q; want sin cambios", got)
+	}
+}
@@ -0,0 +1,68 @@
+package pdf
+
+import "fmt"
+
+// bookletSheet is a landscape sheet with two pages side by side, folded in the middle
+var bookletSheet = layout{width: sheetHeight, height: sheetWidth, cols: 2, rows: 1}
+
+// bookletSides orders the page indexes so the printed sheets, folded and
+// nested, read in order. Every signature takes that many pages, the whole
+// document when signature is 0, and is padded with blank cells (-1) to a
+// multiple of 4. Sides alternate front and back.
+func bookletSides(indexes []int, signature int) [][]int {
+	if signature <= 0 {
+		signature = len(indexes)
+	}
+	var sides [][]int
+	for start := 0; start < len(indexes); start += signature {
+		pages := indexes[start:min(start+signature, len(indexes))]
+		n := (len(pages) + 3) / 4 * 4
+		page := func(i int) int {
+			if i < len(pages) {
+				return pages[i]
+			}
+			return -1
+		}
+		// The outer sheet holds the first and last pages, the inner ones the middle
+		for k := 0; k < n/2; k += 2 {
+			sides = append(sides,
+				[]int{page(n - 1 - k), page(k)},
+				[]int{page(k + 1), page(n - 2 - k)},
+			)
+		}
+	}
+	return sides
+}
+
+// Booklet returns a document that, printed on both sides flipping on the
+// short edge and folded in half, reads as a booklet. Long documents are split
+// in signatures of the given number of pages, a multiple of 4, or kept whole
+// when signature is 0. Only the 1-based pages listed are kept, every page
+// when pages is nil.
+func Booklet(data []byte, pages []int, signature int) ([]byte, error) {
+	if signature < 0 || signature%4 != 0 {
+		return nil, fmt.Errorf("los cuadernillos deben tener un múltiplo de 4 páginas, no %d", signature)
+	}
+	im, err := newImposer(data)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := selectPages(len(im.pages), pages)
+	if err != nil {
+		return nil, err
+	}
+	return im.impose(bookletSides(indexes, signature), bookletSheet, false)
+}
+
+// BookletSheets returns the sheets used by a booklet of n pages, each one
+// holding 4 pages of a signature
+func BookletSheets(n, signature int) int {
+	if signature <= 0 {
+		signature = max(n, 1)
+	}
+	sheets := n / signature * ((signature + 3) / 4)
+	if rest := n % signature; rest > 0 {
+		sheets += (rest + 3) / 4
+	}
+	return sheets
+}
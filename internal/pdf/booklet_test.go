@@ -0,0 +1,38 @@
+package pdf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBookletSides(t *testing.T) {
+	pages := []int{0, 1, 2, 3, 4, 5}
+	want := [][]int{{-1, 0}, {1, -1}, {5, 2}, {3, 4}}
+	if got := bookletSides(pages, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("bookletSides(6 páginas) = %v; want %v", got, want)
+	}
+
+	// Two signatures of 4, the second one padded
+	want = [][]int{{3, 0}, {1, 2}, {-1, 4}, {5, -1}}
+	if got := bookletSides(pages, 4); !reflect.DeepEqual(got, want) {
+		t.Errorf("bookletSides(6 páginas, 4) = %v; want %v", got, want)
+	}
+	for _, c := range []struct{ n, signature, want int }{{6, 0, 2}, {6, 4, 2}, {20, 8, 5}, {1, 0, 1}} {
+		if got := BookletSheets(c.n, c.signature); got != c.want {
+			t.Errorf("BookletSheets(%d, %d) = %d; want %d", c.n, c.signature, got, c.want)
+		}
+	}
+}
+
+func TestBooklet(t *testing.T) {
+	out, err := Booklet(samplePDF(7), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ValidateData(out); err != nil || got != 4 {
+		t.Errorf("Booklet() = %d caras, %v; want 4", got, err)
+	}
+	if _, err := Booklet(samplePDF(7), nil, 6); err == nil {
+		t.Errorf("Booklet() con cuadernillos de 6 páginas debería fallar")
+	}
+}
@@ -0,0 +1,39 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Collate returns a document with the 1-based pages listed, every page when
+// pages is nil, repeated copies times one copy after the other. With duplex
+// each copy is padded with a blank page to an even count, so the next one
+// starts on a new sheet. The pages are shared, so the file barely grows.
+// Links and other annotations are not kept.
+func Collate(data []byte, pages []int, copies int, duplex bool) ([]byte, error) {
+	if copies < 1 {
+		return nil, fmt.Errorf("no se pueden imprimir %d copias", copies)
+	}
+	c, all, err := openCopy(data)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := selectPages(len(all), pages)
+	if err != nil {
+		return nil, err
+	}
+
+	w := c.w
+	parent := w.Reserve()
+	var out []Ref
+	for range copies {
+		for _, i := range indexes {
+			entries := strings.TrimSpace(c.entries(all[i].Dict, "Annots", "B"))
+			out = append(out, w.Add(fmt.Sprintf("<< %s /Parent %s >>", entries, parent)))
+		}
+		if duplex && len(indexes)%2 == 1 {
+			out = append(out, w.Add(fmt.Sprintf("<< /Type /Page /Parent %s /MediaBox [0 0 %g %g] >>", parent, sheetWidth, sheetHeight)))
+		}
+	}
+	return w.Bytes(w.AddPageTree(parent, out)), nil
+}
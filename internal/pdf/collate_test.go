@@ -0,0 +1,29 @@
+package pdf
+
+import "testing"
+
+func TestCollate(t *testing.T) {
+	tests := []struct {
+		pages  []int
+		copies int
+		duplex bool
+		want   int
+	}{
+		{nil, 3, false, 9},
+		{nil, 3, true, 12},
+		{[]int{1, 2}, 3, true, 6},
+		{[]int{2}, 2, false, 2},
+	}
+	for _, tt := range tests {
+		out, err := Collate(samplePDF(3), tt.pages, tt.copies, tt.duplex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := ValidateData(out); err != nil || got != tt.want {
+			t.Errorf("Collate(%v, %d, duplex %v) = %d páginas, %v; want %d", tt.pages, tt.copies, tt.duplex, got, err, tt.want)
+		}
+	}
+	if _, err := Collate(samplePDF(3), nil, 0, false); err == nil {
+		t.Error("Collate() con 0 copias debería fallar")
+	}
+}
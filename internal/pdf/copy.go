@@ -0,0 +1,136 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// copier moves objects from a Reader into a Writer, renumbering the
+// references as they are found
+type copier struct {
+	r    *Reader
+	w    *Writer
+	refs map[Ref]Ref
+}
+
+// openCopy reads the pages of data to copy them into a new document
+func openCopy(data []byte) (*copier, []Page, error) {
+	r, err := NewReader(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Strings and streams would be copied still encrypted
+	if _, ok := r.Trailer["Encrypt"]; ok {
+		return nil, nil, errors.New("el pdf está cifrado y no se puede reacomodar")
+	}
+	pages, err := r.Pages()
+	if err != nil {
+		return nil, nil, err
+	}
+	// The copied objects may use features of the source version
+	w := NewWriter()
+	if r.Version > w.Version {
+		w.Version = r.Version
+	}
+	return &copier{r: r, w: w, refs: map[Ref]Ref{}}, pages, nil
+}
+
+// ref copies the indirect object ref and everything it points to. Objects
+// that can't be read are left as null, like most viewers do.
+func (c *copier) ref(ref Ref) Ref {
+	if out, ok := c.refs[ref]; ok {
+		return out
+	}
+	out := c.w.Reserve()
+	c.refs[ref] = out
+
+	o, err := c.r.Object(ref)
+	if err != nil {
+		return out
+	}
+	if s, ok := o.(*Stream); ok {
+		c.w.SetStream(out, c.entries(s.Dict, "Length"), s.Data)
+	} else {
+		c.w.Set(out, c.format(o))
+	}
+	return out
+}
+
+// entries serializes the entries of d without the surrounding << >>,
+// sorted so the output doesn't depend on map order
+func (c *copier) entries(d Dict, skip ...Name) string {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		// Following /Parent would copy the whole page tree
+		if k == "Parent" || contains(skip, Name(k)) {
+			continue
+		}
+		parts = append(parts, formatName(Name(k))+" "+c.format(d[Name(k)]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func contains(names []Name, name Name) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// format serializes o, copying the objects it references
+func (c *copier) format(o Object) string {
+	switch v := o.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		// Keep the point, some entries must be reals even when whole
+		f := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(f, ".") {
+			f += ".0"
+		}
+		return f
+	case Name:
+		return formatName(v)
+	case String:
+		return fmt.Sprintf("<%x>", []byte(v))
+	case Array:
+		parts := make([]string, len(v))
+		for i, e := range v {
+			parts[i] = c.format(e)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	case Dict:
+		return "<< " + c.entries(v) + " >>"
+	case Ref:
+		return c.ref(v).String()
+	}
+	return "null"
+}
+
+// formatName escapes the characters a name can't hold as #xx
+func formatName(n Name) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	for i := 0; i < len(n); i++ {
+		c := n[i]
+		if c < '!' || c > '~' || c == '#' || isDelim(c) {
+			fmt.Fprintf(&b, "#%02x", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,301 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// NUpSizes are the numbers of pages per side accepted by NUp
+var NUpSizes = []int{1, 2, 4, 6, 9}
+
+// A4 in points, the paper of the DCC printers
+const (
+	sheetWidth  = 595.0
+	sheetHeight = 842.0
+	// sheetMargin is kept blank around the sheet, cellGap between pages
+	sheetMargin = 18.0
+	cellGap     = 12.0
+)
+
+// grids are the columns and rows tried for each number of pages per side
+var grids = map[int][][2]int{
+	1: {{1, 1}},
+	2: {{1, 2}, {2, 1}},
+	4: {{2, 2}},
+	6: {{2, 3}, {3, 2}},
+	9: {{3, 3}},
+}
+
+// layout is a sheet split in a grid of cells filled in reading order
+type layout struct {
+	width, height float64
+	cols, rows    int
+}
+
+// chooseLayout picks the sheet orientation and grid where pages of the
+// given size come out biggest, so slides go on landscape sheets and
+// portrait documents on portrait ones
+func chooseLayout(n int, pageWidth, pageHeight float64) layout {
+	var best layout
+	bestScale := -1.0
+	for _, grid := range grids[n] {
+		for _, sheet := range [][2]float64{{sheetWidth, sheetHeight}, {sheetHeight, sheetWidth}} {
+			l := layout{width: sheet[0], height: sheet[1], cols: grid[0], rows: grid[1]}
+			_, _, w, h := l.cell(0)
+			if scale := math.Min(w/pageWidth, h/pageHeight); scale > bestScale+1e-9 {
+				best, bestScale = l, scale
+			}
+		}
+	}
+	return best
+}
+
+// cell returns the position and size of the i-th cell, counted from the top left
+func (l layout) cell(i int) (x, y, w, h float64) {
+	w = (l.width - 2*sheetMargin - float64(l.cols-1)*cellGap) / float64(l.cols)
+	h = (l.height - 2*sheetMargin - float64(l.rows-1)*cellGap) / float64(l.rows)
+	col, row := i%l.cols, i/l.cols
+	x = sheetMargin + float64(col)*(w+cellGap)
+	y = l.height - sheetMargin - float64(row+1)*h - float64(row)*cellGap
+	return x, y, w, h
+}
+
+// form is a source page turned into a form xobject
+type form struct {
+	ref    Ref
+	box    [4]float64
+	rotate int
+}
+
+// size returns the page as it is shown, after its rotation
+func (f form) size() (float64, float64) {
+	w, h := f.box[2]-f.box[0], f.box[3]-f.box[1]
+	if f.rotate%180 != 0 {
+		return h, w
+	}
+	return w, h
+}
+
+// place returns the operators that draw f centered in the given cell
+func (f form) place(name string, x, y, w, h float64, border bool) string {
+	pw, ph := f.size()
+	scale := math.Min(w/pw, h/ph)
+	ox, oy := x+(w-pw*scale)/2, y+(h-ph*scale)/2
+
+	bw, bh := f.box[2]-f.box[0], f.box[3]-f.box[1]
+	var rotation string
+	switch f.rotate {
+	case 90:
+		rotation = fmt.Sprintf("0 -1 1 0 0 %.4f", bw)
+	case 180:
+		rotation = fmt.Sprintf("-1 0 0 -1 %.4f %.4f", bw, bh)
+	case 270:
+		rotation = fmt.Sprintf("0 1 -1 0 %.4f 0", bh)
+	default:
+		rotation = "1 0 0 1 0 0"
+	}
+
+	var b strings.Builder
+	if border {
+		fmt.Fprintf(&b, "q 0.5 w 0 G %.4f %.4f %.4f %.4f re S Q\n", ox, oy, pw*scale, ph*scale)
+	}
+	fmt.Fprintf(&b, "q %.4f 0 0 %.4f %.4f %.4f cm %s cm 1 0 0 1 %.4f %.4f cm /%s Do Q\n",
+		scale, scale, ox, oy, rotation, -f.box[0], -f.box[1], name)
+	return b.String()
+}
+
+// imposer lays pages of a document on new sheets
+type imposer struct {
+	*copier
+	pages []Page
+	forms map[int]form
+}
+
+func newImposer(data []byte) (*imposer, error) {
+	c, pages, err := openCopy(data)
+	if err != nil {
+		return nil, err
+	}
+	return &imposer{copier: c, pages: pages, forms: map[int]form{}}, nil
+}
+
+// form returns page i as a form xobject, copying it the first time
+func (im *imposer) form(i int) (form, error) {
+	if f, ok := im.forms[i]; ok {
+		return f, nil
+	}
+	page := im.pages[i]
+
+	f := form{box: [4]float64{0, 0, sheetWidth, sheetHeight}}
+	box := page.Dict["CropBox"]
+	if box == nil {
+		box = page.Dict["MediaBox"]
+	}
+	if rect, ok := im.rect(box); ok {
+		f.box = rect
+	}
+	if rotate, err := im.r.Resolve(page.Dict["Rotate"]); err == nil {
+		if n, ok := rotate.(int); ok && n%90 == 0 {
+			f.rotate = (n%360 + 360) % 360
+		}
+	}
+
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Form /BBox [%g %g %g %g] /Resources %s",
+		f.box[0], f.box[1], f.box[2], f.box[3], im.resources(page.Dict["Resources"]))
+
+	contents, err := im.r.Resolve(page.Dict["Contents"])
+	if err != nil {
+		return form{}, fmt.Errorf("la página %d está dañada: %w", i+1, err)
+	}
+	switch v := contents.(type) {
+	case *Stream:
+		// A single stream keeps its filters and is copied as is
+		var filters []string
+		for _, name := range []Name{"Filter", "DecodeParms"} {
+			if o, ok := v.Dict[name]; ok {
+				filters = append(filters, formatName(name)+" "+im.format(o))
+			}
+		}
+		f.ref = im.w.AddStream(strings.TrimSpace(dict+" "+strings.Join(filters, " ")), v.Data)
+	case Array:
+		// A form has a single stream, so the parts are joined decoded
+		var data []byte
+		for _, part := range v {
+			o, err := im.r.Resolve(part)
+			if err != nil {
+				return form{}, fmt.Errorf("la página %d está dañada: %w", i+1, err)
+			}
+			s, ok := o.(*Stream)
+			if !ok {
+				continue
+			}
+			decoded, err := s.Decode()
+			if err != nil {
+				return form{}, fmt.Errorf("la página %d: %w", i+1, err)
+			}
+			data = append(append(data, decoded...), '\n')
+		}
+		f.ref = im.w.AddCompressedStream(dict, data)
+	default:
+		f.ref = im.w.AddStream(dict, nil)
+	}
+
+	im.forms[i] = f
+	return f, nil
+}
+
+// resources copies the resources of a page, an empty dictionary if it has none
+func (im *imposer) resources(o Object) string {
+	if o == nil {
+		return "<< >>"
+	}
+	return im.format(o)
+}
+
+// rect reads a rectangle like /MediaBox, normalized so it goes from the lower left
+func (im *imposer) rect(o Object) ([4]float64, bool) {
+	var rect [4]float64
+	o, err := im.r.Resolve(o)
+	arr, ok := o.(Array)
+	if err != nil || !ok || len(arr) != 4 {
+		return rect, false
+	}
+	for i, e := range arr {
+		e, _ = im.r.Resolve(e)
+		switch n := e.(type) {
+		case int:
+			rect[i] = float64(n)
+		case float64:
+			rect[i] = n
+		default:
+			return rect, false
+		}
+	}
+	rect = [4]float64{min(rect[0], rect[2]), min(rect[1], rect[3]), max(rect[0], rect[2]), max(rect[1], rect[3])}
+	if rect[2]-rect[0] < 1 || rect[3]-rect[1] < 1 {
+		return rect, false
+	}
+	return rect, true
+}
+
+// impose writes a document with a sheet side for every entry of sides,
+// each listing the page indexes that fill the cells of l, -1 for a blank cell
+func (im *imposer) impose(sides [][]int, l layout, border bool) ([]byte, error) {
+	w := im.w
+	parent := w.Reserve()
+	var out []Ref
+	for _, side := range sides {
+		var content strings.Builder
+		var xobjects []string
+		for cell, i := range side {
+			if i < 0 {
+				continue
+			}
+			f, err := im.form(i)
+			if err != nil {
+				return nil, err
+			}
+			name := fmt.Sprintf("P%d", cell+1)
+			x, y, cw, ch := l.cell(cell)
+			content.WriteString(f.place(name, x, y, cw, ch, border))
+			xobjects = append(xobjects, fmt.Sprintf("/%s %s", name, f.ref))
+		}
+		stream := w.AddCompressedStream("", []byte(content.String()))
+		out = append(out, w.Add(fmt.Sprintf("<< /Type /Page /Parent %s /MediaBox [0 0 %g %g] /Resources << /XObject << %s >> >> /Contents %s >>",
+			parent, l.width, l.height, strings.Join(xobjects, " "), stream)))
+	}
+	return w.Bytes(w.AddPageTree(parent, out)), nil
+}
+
+// selectPages turns 1-based page numbers into indexes of a document of
+// total pages, all of them when pages is nil
+func selectPages(total int, pages []int) ([]int, error) {
+	var indexes []int
+	if pages == nil {
+		for i := 0; i < total; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+	for _, p := range pages {
+		if p >= 1 && p <= total {
+			indexes = append(indexes, p-1)
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, errors.New("no hay páginas para imprimir")
+	}
+	return indexes, nil
+}
+
+// NUp returns a document with n pages on each side, in reading order and
+// scaled to fit, with a thin border around each page when border is set.
+// Only the 1-based pages listed are kept, every page when pages is nil.
+func NUp(data []byte, pages []int, n int, border bool) ([]byte, error) {
+	if !slices.Contains(NUpSizes, n) {
+		return nil, fmt.Errorf("no se pueden poner %d páginas por cara (usa 1, 2, 4, 6 o 9)", n)
+	}
+	im, err := newImposer(data)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := selectPages(len(im.pages), pages)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := im.form(indexes[0])
+	if err != nil {
+		return nil, err
+	}
+	pw, ph := first.size()
+	l := chooseLayout(n, pw, ph)
+
+	var sides [][]int
+	for start := 0; start < len(indexes); start += n {
+		sides = append(sides, indexes[start:min(start+n, len(indexes))])
+	}
+	return im.impose(sides, l, border)
+}
@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNUp(t *testing.T) {
+	data := samplePDF(7)
+	cases := []struct {
+		n     int
+		pages []int
+		want  int
+	}{
+		{2, nil, 4},
+		{4, nil, 2},
+		{9, nil, 1},
+		{4, []int{2, 3, 4, 5, 6}, 2},
+		{1, []int{7}, 1},
+	}
+	for _, c := range cases {
+		out, err := NUp(data, c.pages, c.n, true)
+		if err != nil {
+			t.Errorf("NUp(%d, %v) devolvió error: %v", c.n, c.pages, err)
+			continue
+		}
+		if got, err := ValidateData(out); err != nil || got != c.want {
+			t.Errorf("NUp(%d, %v) = %d caras, %v; want %d", c.n, c.pages, got, err, c.want)
+		}
+	}
+
+	// Rotated pages come from an object stream and keep their inherited size
+	if out, err := NUp(xrefStreamPDF(), nil, 2, false); err != nil || !bytes.Contains(out, []byte("/Subtype /Form /BBox [0 0 612 792]")) {
+		t.Errorf("NUp() con xref comprimida = %v", err)
+	}
+	if _, err := NUp(data, nil, 3, false); err == nil {
+		t.Errorf("NUp() con 3 páginas por cara debería fallar")
+	}
+	if _, err := NUp(data, []int{10}, 2, false); err == nil {
+		t.Errorf("NUp() sin páginas en el rango debería fallar")
+	}
+}
+
+func TestChooseLayout(t *testing.T) {
+	cases := []struct {
+		n             int
+		width, height float64
+		landscape     bool
+		cols, rows    int
+	}{
+		// Slides in 4:3 go stacked on a portrait sheet, or 2x2 on a landscape one
+		{2, 364, 273, false, 1, 2},
+		{4, 364, 273, true, 2, 2},
+		{6, 364, 273, false, 2, 3},
+		// A4 documents go side by side on a landscape sheet
+		{2, 595, 842, true, 2, 1},
+		{4, 595, 842, false, 2, 2},
+		{6, 595, 842, true, 3, 2},
+	}
+	for _, c := range cases {
+		l := chooseLayout(c.n, c.width, c.height)
+		if (l.width > l.height) != c.landscape || l.cols != c.cols || l.rows != c.rows {
+			t.Errorf("chooseLayout(%d, %gx%g) = %gx%g %dx%d; want horizontal=%v %dx%d",
+				c.n, c.width, c.height, l.width, l.height, l.cols, l.rows, c.landscape, c.cols, c.rows)
+		}
+	}
+}
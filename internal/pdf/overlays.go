@@ -0,0 +1,268 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// pageLabels returns the label of each of n pages, or nil when the document
+// has no /PageLabels. Only equality matters, so numbers aren't formatted.
+func (r *Reader) pageLabels(n int) []string {
+	catalog, err := r.Catalog()
+	if err != nil {
+		return nil
+	}
+	ranges := map[int]Dict{}
+	visited := map[Ref]bool{}
+	var walk func(o Object, depth int)
+	walk = func(o Object, depth int) {
+		if ref, ok := o.(Ref); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		node, ok := r.resolveDict(o)
+		if !ok || depth > 32 {
+			return
+		}
+		if nums, err := r.Resolve(node["Nums"]); err == nil {
+			if arr, ok := nums.(Array); ok {
+				for i := 0; i+1 < len(arr); i += 2 {
+					start, ok := arr[i].(int)
+					label, isDict := r.resolveDict(arr[i+1])
+					if ok && isDict {
+						ranges[start] = label
+					}
+				}
+			}
+		}
+		if kids, err := r.Resolve(node["Kids"]); err == nil {
+			if arr, ok := kids.(Array); ok {
+				for _, kid := range arr {
+					walk(kid, depth+1)
+				}
+			}
+		}
+	}
+	walk(catalog["PageLabels"], 0)
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	labels := make([]string, n)
+	start, label := 0, Dict{}
+	for i := range labels {
+		if d, ok := ranges[i]; ok {
+			start, label = i, d
+		}
+		prefix, _ := r.Resolve(label["P"])
+		text, _ := prefix.(String)
+		// Pages of a numbered range never share a label
+		if style, ok := label["S"]; ok {
+			first, _ := label["St"].(int)
+			labels[i] = fmt.Sprintf("%s%v%d", text, style, max(first, 1)+i-start)
+		} else {
+			labels[i] = string(text)
+		}
+	}
+	return labels
+}
+
+// pageMarks returns what the content of page draws, in order: the strings
+// it shows, prefixed with T, and the external objects it paints, as X and
+// their number. Overlays of a frame share the images they draw.
+func (r *Reader) pageMarks(page Page) [][]byte {
+	contents, err := r.Resolve(page.Dict["Contents"])
+	if err != nil {
+		return nil
+	}
+	parts := []Object{contents}
+	if arr, ok := contents.(Array); ok {
+		parts = arr
+	}
+	var data []byte
+	for _, part := range parts {
+		o, err := r.Resolve(part)
+		s, ok := o.(*Stream)
+		if err != nil || !ok {
+			continue
+		}
+		decoded, err := s.Decode()
+		if err != nil {
+			return nil
+		}
+		data = append(append(data, decoded...), '\n')
+	}
+
+	resources, _ := r.resolveDict(page.Dict["Resources"])
+	xobjects, _ := r.resolveDict(resources["XObject"])
+	var marks [][]byte
+	for _, m := range contentMarks(data) {
+		if m[0] == 'X' {
+			// Names are local to the page, the objects they point to are not
+			if ref, ok := xobjects[Name(m[1:])].(Ref); ok {
+				m = []byte(fmt.Sprintf("X%d", ref))
+			}
+		}
+		marks = append(marks, m)
+	}
+	return marks
+}
+
+// contentMarks returns the operands of the text showing operators of a
+// content stream prefixed with T, and the names painted with Do prefixed with X
+func contentMarks(data []byte) [][]byte {
+	p := &parser{data: data}
+	var operands []Object
+	var out [][]byte
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return out
+		}
+		c := p.data[p.pos]
+		if c == '/' || c == '(' || c == '<' || c == '[' || c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9') {
+			o, err := p.object()
+			if err != nil {
+				p.pos++
+				operands = nil
+				continue
+			}
+			operands = append(operands, o)
+			continue
+		}
+
+		op := p.token()
+		if op == "" {
+			p.pos++
+			continue
+		}
+		var last Object
+		if len(operands) > 0 {
+			last = operands[len(operands)-1]
+		}
+		switch op {
+		case "Tj", "'", `"`:
+			if s, ok := last.(String); ok {
+				out = append(out, append([]byte("T"), s...))
+			}
+		case "TJ":
+			if arr, ok := last.(Array); ok {
+				for _, e := range arr {
+					if s, ok := e.(String); ok {
+						out = append(out, append([]byte("T"), s...))
+					}
+				}
+			}
+		case "Do":
+			if name, ok := last.(Name); ok {
+				out = append(out, []byte("X"+name))
+			}
+		case "ID":
+			// Inline image data is binary, skip it up to EI
+			end := bytes.Index(p.data[p.pos:], []byte("EI"))
+			if end < 0 {
+				return out
+			}
+			p.pos += end + 2
+		}
+		operands = operands[:0]
+	}
+}
+
+// landscape reports whether page is wider than tall, as slides are
+func (r *Reader) landscape(page Page) bool {
+	box, _ := r.Resolve(page.Dict["MediaBox"])
+	arr, ok := box.(Array)
+	if !ok || len(arr) != 4 {
+		return false
+	}
+	var v [4]float64
+	for i, e := range arr {
+		switch n := e.(type) {
+		case int:
+			v[i] = float64(n)
+		case float64:
+			v[i] = n
+		}
+	}
+	w, h := v[2]-v[0], v[3]-v[1]
+	if rotate, _ := page.Dict["Rotate"].(int); rotate%180 != 0 {
+		w, h = h, w
+	}
+	return w*w > h*h
+}
+
+// isSubsequence reports whether every string of a appears in b in the same order
+func isSubsequence(a, b [][]byte) bool {
+	i := 0
+	for _, s := range b {
+		if i < len(a) && bytes.Equal(a[i], s) {
+			i++
+		}
+	}
+	return i == len(a)
+}
+
+// frames groups the selected page indexes by Beamer frame. Overlays of a
+// frame share their page label. Without labels, a slide is taken as an
+// overlay of the next one when the next draws everything it draws and more.
+func (r *Reader) frames(pages []Page, indexes []int) [][]int {
+	labels := r.pageLabels(len(pages))
+	marks := map[int][][]byte{}
+	mark := func(i int) [][]byte {
+		if m, ok := marks[i]; ok {
+			return m
+		}
+		marks[i] = r.pageMarks(pages[i])
+		return marks[i]
+	}
+
+	var frames [][]int
+	for k, i := range indexes {
+		overlay := false
+		// Only slides are looked at, they are wider than tall
+		if k > 0 && indexes[k-1] == i-1 && r.landscape(pages[i-1]) && r.landscape(pages[i]) {
+			if labels != nil {
+				overlay = labels[i] != "" && labels[i] == labels[i-1]
+			} else {
+				prev := mark(i - 1)
+				overlay = len(prev) > 0 && isSubsequence(prev, mark(i))
+			}
+		}
+		if overlay {
+			frames[len(frames)-1] = append(frames[len(frames)-1], i)
+		} else {
+			frames = append(frames, []int{i})
+		}
+	}
+	return frames
+}
+
+// CollapseOverlays returns a document with only the last page of each Beamer
+// frame among the 1-based pages listed, every page when pages is nil, and the
+// number of pages left. Links and other annotations are not kept.
+func CollapseOverlays(data []byte, pages []int) ([]byte, int, error) {
+	c, all, err := openCopy(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	indexes, err := selectPages(len(all), pages)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	w := c.w
+	parent := w.Reserve()
+	var out []Ref
+	for _, frame := range c.r.frames(all, indexes) {
+		page := all[frame[len(frame)-1]]
+		ref := w.Reserve()
+		entries := strings.TrimSpace(c.entries(page.Dict, "Annots", "B"))
+		w.Set(ref, fmt.Sprintf("<< %s /Parent %s >>", entries, parent))
+		out = append(out, ref)
+	}
+	return w.Bytes(w.AddPageTree(parent, out)), len(out), nil
+}
@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// slidesPDF writes landscape pages showing the given lines of text, with
+// the page labels in labels when it isn't empty
+func slidesPDF(pages [][]string, labels []string) []byte {
+	w := NewWriter()
+	parent := w.Reserve()
+	var refs []Ref
+	for _, lines := range pages {
+		var content strings.Builder
+		for i, line := range lines {
+			fmt.Fprintf(&content, "BT /F1 12 Tf 20 %d Td %s Tj ET\n", 240-20*i, Text(line))
+		}
+		stream := w.AddStream("", []byte(content.String()))
+		refs = append(refs, w.Add(fmt.Sprintf("<< /Type /Page /Parent %s /MediaBox [0 0 364 273] /Contents %s >>", parent, stream)))
+	}
+	w.AddPageTree(parent, refs)
+
+	var nums []string
+	for i, label := range labels {
+		nums = append(nums, fmt.Sprintf("%d << /P %s >>", i, Text(label)))
+	}
+	catalog := "<< /Type /Catalog /Pages " + parent.String()
+	if len(nums) > 0 {
+		catalog += " /PageLabels << /Nums [" + strings.Join(nums, " ") + "] >>"
+	}
+	return w.Bytes(w.Add(catalog + " >>"))
+}
+
+func TestCollapseOverlays(t *testing.T) {
+	pages := [][]string{
+		{"Título"},
+		{"Motivación", "uno"},
+		{"Motivación", "uno", "dos"},
+		{"Motivación", "uno", "dos", "tres"},
+		{"Conclusión"},
+		{"Conclusión", "fin"},
+	}
+	cases := []struct {
+		name   string
+		labels []string
+		pages  []int
+		want   int
+	}{
+		{"etiquetas", []string{"1", "2", "2", "2", "3", "4"}, nil, 4},
+		{"texto", nil, nil, 3},
+		{"rango", nil, []int{1, 2, 3}, 2},
+	}
+	for _, c := range cases {
+		out, n, err := CollapseOverlays(slidesPDF(pages, c.labels), c.pages)
+		if err != nil {
+			t.Errorf("%s: CollapseOverlays() devolvió error: %v", c.name, err)
+			continue
+		}
+		if got, err := ValidateData(out); err != nil || n != c.want || got != c.want {
+			t.Errorf("%s: CollapseOverlays() = %d páginas (%d, %v); want %d", c.name, n, got, err, c.want)
+		}
+	}
+
+	// The last overlay of each frame is the one kept
+	out, _, _ := CollapseOverlays(slidesPDF(pages, nil), nil)
+	r, _ := NewReader(out)
+	kept, _ := r.Pages()
+	if marks := r.pageMarks(kept[1]); len(marks) != 4 {
+		t.Errorf("se guardó %q; want la última página de Motivación", marks)
+	}
+
+	// Portrait documents are never collapsed
+	if _, n, _ := CollapseOverlays(samplePDF(3), nil); n != 3 {
+		t.Errorf("CollapseOverlays() de un documento vertical = %d páginas; want 3", n)
+	}
+}
@@ -0,0 +1,178 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Page is a leaf of the page tree with its inherited attributes filled in
+type Page struct {
+	Ref  Ref
+	Dict Dict
+}
+
+// Attributes a page takes from its ancestors when it doesn't set them
+var inheritable = []Name{"Resources", "MediaBox", "CropBox", "Rotate"}
+
+// Catalog returns the root dictionary of the document
+func (r *Reader) Catalog() (Dict, error) {
+	catalog, ok := r.resolveDict(r.Trailer["Root"])
+	if !ok {
+		return nil, errors.New("no se pudo leer el catálogo")
+	}
+	return catalog, nil
+}
+
+// Pages walks the page tree and returns the pages in order
+func (r *Reader) Pages() ([]Page, error) {
+	catalog, err := r.Catalog()
+	if err != nil {
+		return nil, err
+	}
+	root, ok := catalog["Pages"].(Ref)
+	if !ok {
+		return nil, errors.New("el catálogo no tiene árbol de páginas")
+	}
+
+	var pages []Page
+	visited := map[Ref]bool{}
+	var walk func(ref Ref, inherited Dict, depth int) error
+	walk = func(ref Ref, inherited Dict, depth int) error {
+		if visited[ref] || depth > 64 {
+			return errors.New("el árbol de páginas tiene un ciclo")
+		}
+		visited[ref] = true
+		node, ok := r.resolveDict(ref)
+		if !ok {
+			return fmt.Errorf("falta el nodo %d del árbol de páginas", ref)
+		}
+
+		kids, hasKids := node["Kids"]
+		if node["Type"] == Name("Page") || (!hasKids && node["Type"] != Name("Pages")) {
+			page := Dict{}
+			for k, v := range node {
+				page[k] = v
+			}
+			for _, name := range inheritable {
+				if _, ok := page[name]; !ok && inherited[name] != nil {
+					page[name] = inherited[name]
+				}
+			}
+			pages = append(pages, Page{Ref: ref, Dict: page})
+			return nil
+		}
+
+		next := Dict{}
+		for k, v := range inherited {
+			next[k] = v
+		}
+		for _, name := range inheritable {
+			if v, ok := node[name]; ok {
+				next[name] = v
+			}
+		}
+		kidsArray, err := r.Resolve(kids)
+		if err != nil {
+			return err
+		}
+		list, ok := kidsArray.(Array)
+		if !ok {
+			return fmt.Errorf("el nodo %d del árbol de páginas no tiene /Kids", ref)
+		}
+		for _, kid := range list {
+			kidRef, ok := kid.(Ref)
+			if !ok {
+				return fmt.Errorf("el nodo %d tiene un hijo que no es una referencia", ref)
+			}
+			if err := walk(kidRef, next, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(root, Dict{}, 0); err != nil {
+		return nil, err
+	}
+
+	node, _ := r.resolveDict(root)
+	if count, err := r.Resolve(node["Count"]); err == nil {
+		if n, ok := count.(int); ok && n != len(pages) {
+			return nil, fmt.Errorf("el árbol de páginas dice tener %d páginas pero tiene %d", n, len(pages))
+		}
+	}
+	return pages, nil
+}
+
+// Validate checks what a printer needs from the pdf at path: the header,
+// a readable xref and trailer, the catalog and a page tree with at least
+// one page, every page with a size and readable contents. It returns the
+// number of pages.
+func Validate(path string) (int, error) {
+	r, err := Open(path)
+	if err != nil {
+		return 0, err
+	}
+	return r.validate()
+}
+
+// ValidateData is Validate for a pdf already in memory
+func ValidateData(data []byte) (int, error) {
+	r, err := NewReader(data)
+	if err != nil {
+		return 0, err
+	}
+	return r.validate()
+}
+
+// Encrypted reports whether the pdf at path declares an /Encrypt dictionary.
+// Its strings and streams, and so the objects in object streams, can't be
+// read without decrypting them first.
+func Encrypted(path string) bool {
+	r, err := Open(path)
+	if err != nil {
+		return false
+	}
+	_, ok := r.Trailer["Encrypt"]
+	return ok
+}
+
+func (r *Reader) validate() (int, error) {
+	pages, err := r.Pages()
+	if err != nil {
+		return 0, err
+	}
+	if len(pages) == 0 {
+		return 0, errors.New("el pdf no tiene páginas")
+	}
+	for i, page := range pages {
+		if _, ok := page.Dict["MediaBox"]; !ok {
+			return 0, fmt.Errorf("la página %d no tiene tamaño (/MediaBox)", i+1)
+		}
+		if err := r.checkContents(page.Dict["Contents"]); err != nil {
+			return 0, fmt.Errorf("la página %d está dañada: %w", i+1, err)
+		}
+	}
+	return len(pages), nil
+}
+
+// checkContents makes sure the content streams of a page can be read,
+// which catches files cut in the middle of a download
+func (r *Reader) checkContents(contents Object) error {
+	o, err := r.Resolve(contents)
+	if err != nil {
+		return err
+	}
+	switch v := o.(type) {
+	case nil, *Stream:
+		return nil
+	case Array:
+		for _, part := range v {
+			if err := r.checkContents(part); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return errors.New("/Contents no es un stream")
+}
@@ -0,0 +1,275 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Object is a parsed pdf value: nil, bool, int, float64, String, Name,
+// Array, Dict, Ref or *Stream
+type Object any
+
+// Name is a pdf name without the leading slash
+type Name string
+
+// String is the decoded content of a literal or hex string
+type String []byte
+
+type Array []Object
+
+type Dict map[Name]Object
+
+// Stream is a dictionary followed by its data, still encoded
+type Stream struct {
+	Dict Dict
+	Data []byte
+}
+
+var errEOF = errors.New("fin inesperado del archivo")
+
+// parser reads pdf syntax from data starting at pos
+type parser struct {
+	data []byte
+	pos  int
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and comments
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if c == '%' {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return
+		}
+		p.pos++
+	}
+}
+
+// token reads a run of regular characters, like a number or a keyword
+func (p *parser) token() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.data) && !isSpace(p.data[p.pos]) && !isDelim(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// object reads the next value. References are resolved by the caller.
+func (p *parser) object() (Object, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, errEOF
+	}
+
+	switch c := p.data[p.pos]; c {
+	case '/':
+		return p.name(), nil
+	case '(':
+		return p.literalString()
+	case '<':
+		if p.pos+1 < len(p.data) && p.data[p.pos+1] == '<' {
+			return p.dict()
+		}
+		return p.hexString()
+	case '[':
+		return p.array()
+	case ']', '>', ')', '{', '}':
+		return nil, fmt.Errorf("carácter inesperado %q en el byte %d", c, p.pos)
+	}
+
+	start := p.pos
+	tok := p.token()
+	switch tok {
+	case "":
+		return nil, fmt.Errorf("carácter inesperado %q en el byte %d", p.data[p.pos], p.pos)
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+
+	if n, err := strconv.Atoi(tok); err == nil {
+		// Two integers followed by R are a reference
+		save := p.pos
+		if gen, err := strconv.Atoi(p.token()); err == nil && gen >= 0 && p.token() == "R" {
+			return Ref(n), nil
+		}
+		p.pos = save
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(tok, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("valor inválido %q en el byte %d", tok, start)
+}
+
+func (p *parser) name() Name {
+	p.pos++
+	var b []byte
+	for p.pos < len(p.data) && !isSpace(p.data[p.pos]) && !isDelim(p.data[p.pos]) {
+		c := p.data[p.pos]
+		if c == '#' && p.pos+2 < len(p.data) {
+			if v, err := strconv.ParseUint(string(p.data[p.pos+1:p.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				p.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		p.pos++
+	}
+	return Name(b)
+}
+
+func (p *parser) literalString() (String, error) {
+	p.pos++
+	var b []byte
+	depth := 1
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return String(b), nil
+			}
+		case '\\':
+			if p.pos >= len(p.data) {
+				return nil, errEOF
+			}
+			c = p.data[p.pos]
+			p.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// A backslash at the end of the line continues the string
+				if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+					p.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					v := int(c - '0')
+					for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+						v = v*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					c = byte(v)
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return nil, errEOF
+}
+
+func (p *parser) hexString() (String, error) {
+	p.pos++
+	end := bytes.IndexByte(p.data[p.pos:], '>')
+	if end < 0 {
+		return nil, errEOF
+	}
+	var digits []byte
+	for _, c := range p.data[p.pos : p.pos+end] {
+		if !isSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	p.pos += end + 1
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	b := make([]byte, len(digits)/2)
+	for i := range b {
+		v, err := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("string hexadecimal inválido en el byte %d", p.pos)
+		}
+		b[i] = byte(v)
+	}
+	return String(b), nil
+}
+
+func (p *parser) array() (Array, error) {
+	p.pos++
+	arr := Array{}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return nil, errEOF
+		}
+		if p.data[p.pos] == ']' {
+			p.pos++
+			return arr, nil
+		}
+		o, err := p.object()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, o)
+	}
+}
+
+func (p *parser) dict() (Dict, error) {
+	p.pos += 2
+	d := Dict{}
+	for {
+		p.skipSpace()
+		if p.pos+1 >= len(p.data) {
+			return nil, errEOF
+		}
+		if p.data[p.pos] == '>' && p.data[p.pos+1] == '>' {
+			p.pos += 2
+			return d, nil
+		}
+		if p.data[p.pos] != '/' {
+			return nil, fmt.Errorf("se esperaba un nombre en el byte %d", p.pos)
+		}
+		key := p.name()
+		value, err := p.object()
+		if err != nil {
+			return nil, err
+		}
+		d[key] = value
+	}
+}
@@ -0,0 +1,634 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// xrefEntry locates an object, either at an offset of the file or
+// as the index-th object of the object stream number stream
+type xrefEntry struct {
+	offset int
+	stream int
+	index  int
+}
+
+// Reader gives access to the objects of a pdf held in memory
+type Reader struct {
+	data    []byte
+	xref    map[int]xrefEntry
+	Trailer Dict
+	// Version is the one in the header, like "1.7"
+	Version string
+	// Repaired is set when the xref was broken and rebuilt scanning the file
+	Repaired bool
+	cache    map[int]Object
+	loading  map[int]bool
+	objStms  map[int]*objectStream
+}
+
+// objectStream is a decoded stream of objects, indexed by object number
+type objectStream struct {
+	data   []byte
+	index  map[int]int
+	starts []int
+}
+
+// Open reads the pdf at path
+func Open(path string) (*Reader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader(data)
+}
+
+// NewReader checks the header and loads the xref of a pdf.
+// A damaged xref is rebuilt from the objects found in the file.
+func NewReader(data []byte) (*Reader, error) {
+	header := data[:min(len(data), 1024)]
+	start := bytes.Index(header, []byte("%PDF-"))
+	if start < 0 {
+		return nil, errors.New("no tiene la cabecera %PDF, no es un pdf")
+	}
+
+	r := &Reader{
+		data:    data,
+		xref:    map[int]xrefEntry{},
+		cache:   map[int]Object{},
+		loading: map[int]bool{},
+		objStms: map[int]*objectStream{},
+	}
+	if m := versionRe.FindSubmatch(header[start:]); m != nil {
+		r.Version = string(m[1])
+	}
+	if err := r.loadXref(); err != nil {
+		if repairErr := r.repair(); repairErr != nil {
+			return nil, fmt.Errorf("xref dañada (%v) y no se pudo reconstruir: %w", err, repairErr)
+		}
+	}
+	if _, ok := r.Trailer["Root"]; !ok {
+		if err := r.repair(); err != nil {
+			return nil, fmt.Errorf("el trailer no tiene /Root: %w", err)
+		}
+	}
+	return r, nil
+}
+
+var versionRe = regexp.MustCompile(`^%PDF-(\d\.\d)`)
+
+var startxrefRe = regexp.MustCompile(`startxref\s+(\d+)`)
+
+// loadXref follows the chain of xref sections from the last startxref
+func (r *Reader) loadXref() error {
+	tail := r.data[max(0, len(r.data)-2048):]
+	matches := startxrefRe.FindAllSubmatch(tail, -1)
+	if matches == nil {
+		return errors.New("no se encontró startxref")
+	}
+	offset, _ := strconv.Atoi(string(matches[len(matches)-1][1]))
+
+	seen := map[int]bool{}
+	first := true
+	for offset > 0 {
+		if seen[offset] {
+			return errors.New("la cadena de xref tiene un ciclo")
+		}
+		seen[offset] = true
+		if offset >= len(r.data) {
+			return fmt.Errorf("startxref apunta fuera del archivo (%d)", offset)
+		}
+
+		trailer, err := r.readXrefSection(offset)
+		if err != nil {
+			return err
+		}
+		// Hybrid files keep the compressed objects in an extra xref stream
+		if stm, ok := trailer["XRefStm"].(int); ok && !seen[stm] {
+			seen[stm] = true
+			if _, err := r.readXrefSection(stm); err != nil {
+				return err
+			}
+		}
+		if first {
+			r.Trailer = trailer
+			first = false
+		}
+		prev, _ := trailer["Prev"].(int)
+		offset = prev
+	}
+
+	// Every object pointed by the xref must be where it says
+	for num, e := range r.xref {
+		if e.stream == 0 && e.offset >= 0 && !r.objectAt(num, e.offset) {
+			return fmt.Errorf("el objeto %d no está donde indica la xref", num)
+		}
+	}
+	return nil
+}
+
+var objHeaderRe = regexp.MustCompile(`^\s*(\d+)\s+(\d+)\s+obj`)
+
+// objectAt reports whether the object num starts at offset
+func (r *Reader) objectAt(num, offset int) bool {
+	if offset < 0 || offset >= len(r.data) {
+		return false
+	}
+	m := objHeaderRe.FindSubmatch(r.data[offset:min(len(r.data), offset+64)])
+	return m != nil && string(m[1]) == strconv.Itoa(num)
+}
+
+// readXrefSection reads a classic xref table or an xref stream at offset,
+// keeping the entries already known from newer sections
+func (r *Reader) readXrefSection(offset int) (Dict, error) {
+	p := &parser{data: r.data, pos: offset}
+	if p.token() != "xref" {
+		p.pos = offset
+		return r.readXrefStream(p)
+	}
+
+	for {
+		save := p.pos
+		tok := p.token()
+		if tok == "trailer" {
+			break
+		}
+		start, err := strconv.Atoi(tok)
+		if err != nil {
+			p.pos = save
+			return nil, fmt.Errorf("xref inválida en el byte %d", save)
+		}
+		count, err := strconv.Atoi(p.token())
+		if err != nil {
+			return nil, fmt.Errorf("xref inválida en el byte %d", save)
+		}
+		for i := 0; i < count; i++ {
+			off, err1 := strconv.Atoi(p.token())
+			_, err2 := strconv.Atoi(p.token())
+			kind := p.token()
+			if err1 != nil || err2 != nil || (kind != "n" && kind != "f") {
+				return nil, fmt.Errorf("entrada de xref inválida para el objeto %d", start+i)
+			}
+			if _, ok := r.xref[start+i]; !ok && kind == "n" {
+				r.xref[start+i] = xrefEntry{offset: off}
+			} else if !ok {
+				r.xref[start+i] = xrefEntry{offset: -1}
+			}
+		}
+	}
+
+	o, err := p.object()
+	if err != nil {
+		return nil, fmt.Errorf("trailer inválido: %w", err)
+	}
+	trailer, ok := o.(Dict)
+	if !ok {
+		return nil, errors.New("el trailer no es un diccionario")
+	}
+	return trailer, nil
+}
+
+// readXrefStream reads the compressed xref of pdf 1.5 and later
+func (r *Reader) readXrefStream(p *parser) (Dict, error) {
+	_, o, err := r.parseIndirect(p)
+	if err != nil {
+		return nil, fmt.Errorf("xref inválida: %w", err)
+	}
+	stream, ok := o.(*Stream)
+	if !ok || stream.Dict["Type"] != Name("XRef") {
+		return nil, errors.New("startxref no apunta a una xref")
+	}
+	data, err := stream.Decode()
+	if err != nil {
+		return nil, fmt.Errorf("xref ilegible: %w", err)
+	}
+
+	w, _ := stream.Dict["W"].(Array)
+	if len(w) != 3 {
+		return nil, errors.New("xref sin /W")
+	}
+	widths := make([]int, 3)
+	for i := range widths {
+		widths[i], _ = w[i].(int)
+		// A field wider than an int would overflow
+		if widths[i] < 0 || widths[i] > 8 {
+			return nil, fmt.Errorf("xref con /W inválido: %v", w)
+		}
+	}
+	size, _ := stream.Dict["Size"].(int)
+	index := Array{0, size}
+	if idx, ok := stream.Dict["Index"].(Array); ok {
+		index = idx
+	}
+
+	row := widths[0] + widths[1] + widths[2]
+	if row == 0 {
+		return nil, errors.New("xref con /W vacío")
+	}
+	field := func(b []byte) int {
+		v := 0
+		for _, c := range b {
+			v = v<<8 | int(c)
+		}
+		return v
+	}
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, _ := index[i].(int)
+		count, _ := index[i+1].(int)
+		for j := 0; j < count; j++ {
+			if pos+row > len(data) {
+				return stream.Dict, errors.New("xref más corta que su /Index")
+			}
+			kind := 1
+			if widths[0] > 0 {
+				kind = field(data[pos : pos+widths[0]])
+			}
+			a := field(data[pos+widths[0] : pos+widths[0]+widths[1]])
+			b := field(data[pos+widths[0]+widths[1] : pos+row])
+			pos += row
+
+			num := start + j
+			if _, ok := r.xref[num]; ok {
+				continue
+			}
+			switch kind {
+			case 1:
+				r.xref[num] = xrefEntry{offset: a}
+			case 2:
+				r.xref[num] = xrefEntry{stream: a, index: b}
+			default:
+				r.xref[num] = xrefEntry{offset: -1}
+			}
+		}
+	}
+	return stream.Dict, nil
+}
+
+var objScanRe = regexp.MustCompile(`(?m)(\d+)\s+(\d+)\s+obj\b`)
+
+// repair rebuilds the xref scanning the file for "N G obj", the later
+// definitions winning like in an incremental update
+func (r *Reader) repair() error {
+	r.Repaired = true
+	r.xref = map[int]xrefEntry{}
+	r.cache = map[int]Object{}
+	r.objStms = map[int]*objectStream{}
+
+	for _, m := range objScanRe.FindAllSubmatchIndex(r.data, -1) {
+		// The number must start a token, not be the tail of another one
+		if m[0] > 0 && !isSpace(r.data[m[0]-1]) && !isDelim(r.data[m[0]-1]) {
+			continue
+		}
+		num, _ := strconv.Atoi(string(r.data[m[2]:m[3]]))
+		r.xref[num] = xrefEntry{offset: m[0]}
+	}
+	if len(r.xref) == 0 {
+		return errors.New("no tiene objetos")
+	}
+
+	// Objects compressed in object streams are found through their stream
+	for num := range r.xref {
+		o, err := r.Object(Ref(num))
+		stream, ok := o.(*Stream)
+		if err != nil || !ok || stream.Dict["Type"] != Name("ObjStm") {
+			continue
+		}
+		if objStm, err := r.objectStream(num); err == nil {
+			for n, i := range objStm.index {
+				if _, ok := r.xref[n]; !ok {
+					r.xref[n] = xrefEntry{stream: num, index: i}
+				}
+			}
+		}
+	}
+
+	if r.Trailer == nil {
+		r.Trailer = Dict{}
+	}
+	if _, ok := r.Trailer["Root"]; ok {
+		return nil
+	}
+	for num := range r.xref {
+		if d, ok := r.resolveDict(Ref(num)); ok && d["Type"] == Name("Catalog") {
+			r.Trailer["Root"] = Ref(num)
+			return nil
+		}
+	}
+	return errors.New("no se encontró el catálogo")
+}
+
+// Object returns the indirect object ref, or nil when it doesn't exist
+func (r *Reader) Object(ref Ref) (Object, error) {
+	num := int(ref)
+	if o, ok := r.cache[num]; ok {
+		return o, nil
+	}
+	e, ok := r.xref[num]
+	if !ok || e.offset < 0 {
+		return nil, nil
+	}
+	if r.loading[num] {
+		return nil, fmt.Errorf("el objeto %d se contiene a sí mismo", num)
+	}
+	r.loading[num] = true
+	defer delete(r.loading, num)
+
+	var o Object
+	var err error
+	if e.stream > 0 {
+		o, err = r.compressedObject(e)
+	} else {
+		var got int
+		got, o, err = r.parseIndirect(&parser{data: r.data, pos: e.offset})
+		if err == nil && got != num {
+			err = fmt.Errorf("se esperaba el objeto %d y se encontró el %d", num, got)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("objeto %d: %w", num, err)
+	}
+	r.cache[num] = o
+	return o, nil
+}
+
+// Resolve follows o while it is a reference
+func (r *Reader) Resolve(o Object) (Object, error) {
+	for i := 0; i < 32; i++ {
+		ref, ok := o.(Ref)
+		if !ok {
+			return o, nil
+		}
+		var err error
+		if o, err = r.Object(ref); err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("demasiadas referencias encadenadas")
+}
+
+// resolveDict resolves o expecting a dictionary, or the dictionary of a stream
+func (r *Reader) resolveDict(o Object) (Dict, bool) {
+	o, err := r.Resolve(o)
+	if err != nil {
+		return nil, false
+	}
+	switch v := o.(type) {
+	case Dict:
+		return v, true
+	case *Stream:
+		return v.Dict, true
+	}
+	return nil, false
+}
+
+// parseIndirect reads "N G obj ... endobj" at the parser position
+func (r *Reader) parseIndirect(p *parser) (int, Object, error) {
+	num, err := strconv.Atoi(p.token())
+	if err != nil {
+		return 0, nil, errors.New("no empieza un objeto")
+	}
+	if _, err := strconv.Atoi(p.token()); err != nil || p.token() != "obj" {
+		return 0, nil, errors.New("no empieza un objeto")
+	}
+	o, err := p.object()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	dict, ok := o.(Dict)
+	if !ok {
+		return num, o, nil
+	}
+	save := p.pos
+	if p.token() != "stream" {
+		p.pos = save
+		return num, o, nil
+	}
+	// The data starts after the end of line following the keyword
+	if p.pos < len(p.data) && p.data[p.pos] == '\r' {
+		p.pos++
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+		p.pos++
+	}
+	data, err := r.streamData(p, dict)
+	if err != nil {
+		return 0, nil, err
+	}
+	return num, &Stream{Dict: dict, Data: data}, nil
+}
+
+// streamData cuts the data of a stream using /Length, looking for
+// endstream when the length is wrong
+func (r *Reader) streamData(p *parser, dict Dict) ([]byte, error) {
+	start := p.pos
+	length := -1
+	if l, ok := dict["Length"].(int); ok {
+		length = l
+	} else if ref, ok := dict["Length"].(Ref); ok {
+		if l, err := r.Object(ref); err == nil {
+			if n, ok := l.(int); ok {
+				length = n
+			}
+		}
+	}
+
+	if length >= 0 && start+length <= len(p.data) {
+		end := &parser{data: p.data, pos: start + length}
+		if end.token() == "endstream" {
+			p.pos = end.pos
+			return p.data[start : start+length], nil
+		}
+	}
+
+	end := bytes.Index(p.data[start:], []byte("endstream"))
+	if end < 0 {
+		return nil, errors.New("stream sin endstream")
+	}
+	data := p.data[start : start+end]
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	p.pos = start + end + len("endstream")
+	return data, nil
+}
+
+// compressedObject reads an object stored inside an object stream
+func (r *Reader) compressedObject(e xrefEntry) (Object, error) {
+	objStm, err := r.objectStream(e.stream)
+	if err != nil {
+		return nil, err
+	}
+	if e.index < 0 || e.index >= len(objStm.starts) {
+		return nil, fmt.Errorf("no está en el stream de objetos %d", e.stream)
+	}
+	p := &parser{data: objStm.data, pos: objStm.starts[e.index]}
+	return p.object()
+}
+
+// objectStream decodes and indexes the object stream num
+func (r *Reader) objectStream(num int) (*objectStream, error) {
+	if s, ok := r.objStms[num]; ok {
+		return s, nil
+	}
+	o, err := r.Object(Ref(num))
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := o.(*Stream)
+	if !ok {
+		return nil, fmt.Errorf("el objeto %d no es un stream de objetos", num)
+	}
+	data, err := stream.Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	n, _ := stream.Dict["N"].(int)
+	first, _ := stream.Dict["First"].(int)
+	if first < 0 || first > len(data) {
+		return nil, fmt.Errorf("stream de objetos %d inválido", num)
+	}
+	s := &objectStream{data: data, index: map[int]int{}}
+	p := &parser{data: data[:first]}
+	for i := 0; i < n; i++ {
+		objNum, err1 := strconv.Atoi(p.token())
+		offset, err2 := strconv.Atoi(p.token())
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("stream de objetos %d inválido", num)
+		}
+		if offset < 0 || offset > len(data)-first {
+			return nil, fmt.Errorf("stream de objetos %d inválido", num)
+		}
+		s.index[objNum] = i
+		s.starts = append(s.starts, first+offset)
+	}
+	r.objStms[num] = s
+	return s, nil
+}
+
+// Decode returns the data of the stream without its filters.
+// Only FlateDecode, the filter used by almost every pdf writer, is supported.
+func (s *Stream) Decode() ([]byte, error) {
+	filters := []Object{}
+	switch f := s.Dict["Filter"].(type) {
+	case Name:
+		filters = append(filters, f)
+	case Array:
+		filters = f
+	}
+	parms := []Object{}
+	switch d := s.Dict["DecodeParms"].(type) {
+	case Dict:
+		parms = append(parms, d)
+	case Array:
+		parms = d
+	}
+
+	data := s.Data
+	for i, f := range filters {
+		if f != Name("FlateDecode") {
+			return nil, fmt.Errorf("filtro no soportado: %v", f)
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		// Truncated streams still give the data read so far
+		decoded, err := io.ReadAll(zr)
+		if err != nil && len(decoded) == 0 {
+			return nil, err
+		}
+		data = decoded
+		if i < len(parms) {
+			if d, ok := parms[i].(Dict); ok {
+				if data, err = unpredict(data, d); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return data, nil
+}
+
+// unpredict undoes the PNG predictors used by xref and object streams
+func unpredict(data []byte, parms Dict) ([]byte, error) {
+	predictor, _ := parms["Predictor"].(int)
+	if predictor < 10 {
+		return data, nil
+	}
+	columns, ok := parms["Columns"].(int)
+	if !ok {
+		columns = 1
+	}
+	colors, ok := parms["Colors"].(int)
+	if !ok {
+		colors = 1
+	}
+	bpc, ok := parms["BitsPerComponent"].(int)
+	if !ok {
+		bpc = 8
+	}
+	// Each is at most a row of the data, so the product doesn't overflow
+	if columns <= 0 || colors <= 0 || bpc <= 0 || columns > len(data) || colors > 32 || bpc > 32 {
+		return nil, fmt.Errorf("parámetros de predictor inválidos: %v", parms)
+	}
+	bpp := max(1, colors*bpc/8)
+	rowLen := (columns*colors*bpc + 7) / 8
+
+	var out []byte
+	prev := make([]byte, rowLen)
+	for pos := 0; pos+rowLen+1 <= len(data); pos += rowLen + 1 {
+		filter := data[pos]
+		row := append([]byte(nil), data[pos+1:pos+1+rowLen]...)
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up := prev[i]
+			switch filter {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("predictor PNG inválido: %d", filter)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,205 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// samplePDF writes a document of n pages with the Writer
+func samplePDF(n int) []byte {
+	w := NewWriter()
+	parent := w.Reserve()
+	var pages []Ref
+	for i := 0; i < n; i++ {
+		content := w.AddStream("", []byte(fmt.Sprintf("BT /F1 12 Tf (pagina %d) Tj ET", i+1)))
+		pages = append(pages, w.Add(fmt.Sprintf("<< /Type /Page /Parent %s /MediaBox [0 0 595 842] /Contents %s >>", parent, content)))
+	}
+	return w.Bytes(w.AddPageTree(parent, pages))
+}
+
+// xrefStreamPDF writes a pdf 1.5 with the pages inside an object stream
+// and a compressed xref using the PNG up predictor
+func xrefStreamPDF() []byte {
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.5\n")
+	offsets := map[int]int{}
+
+	offsets[1] = out.Len()
+	out.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	offsets[5] = out.Len()
+	out.WriteString("5 0 obj\n<< /Length 0 >>\nstream\n\nendstream\nendobj\n")
+
+	// Objects 2, 3 and 4 live in the object stream 6
+	objs := []string{
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Rotate 90 >>",
+	}
+	var header, body strings.Builder
+	for i, o := range objs {
+		fmt.Fprintf(&header, "%d %d ", i+2, body.Len())
+		body.WriteString(o + " ")
+	}
+	offsets[6] = out.Len()
+	stm := header.String() + body.String()
+	fmt.Fprintf(&out, "6 0 obj\n<< /Type /ObjStm /N 3 /First %d /Length %d >>\nstream\n%s\nendstream\nendobj\n", header.Len(), len(stm), stm)
+
+	// Rows of type(1) field2(2) field3(1), each preceded by the PNG filter byte
+	rows := [][]byte{
+		{0, 0, 0, 0},
+		{1, 0, byte(offsets[1]), 0},
+		{2, 0, 6, 0},
+		{2, 0, 6, 1},
+		{2, 0, 6, 2},
+		{1, 0, byte(offsets[5]), 0},
+		{1, 0, byte(offsets[6]), 0},
+		{1, 0, 0, 0},
+	}
+	xrefOffset := out.Len()
+	rows[7] = []byte{1, byte(xrefOffset >> 8), byte(xrefOffset), 0}
+	var raw bytes.Buffer
+	prev := make([]byte, 4)
+	for _, row := range rows {
+		raw.WriteByte(2)
+		for i, c := range row {
+			raw.WriteByte(c - prev[i])
+		}
+		prev = row
+	}
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(raw.Bytes())
+	zw.Close()
+
+	fmt.Fprintf(&out, "7 0 obj\n<< /Type /XRef /Size 8 /W [1 2 1] /Root 1 0 R /Filter /FlateDecode "+
+		"/DecodeParms << /Predictor 12 /Columns 4 >> /Length %d >>\nstream\n", compressed.Len())
+	out.Write(compressed.Bytes())
+	fmt.Fprintf(&out, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	return out.Bytes()
+}
+
+func writeTemp(t *testing.T, data []byte) string {
+	path := filepath.Join(t.TempDir(), "test.pdf")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidate(t *testing.T) {
+	n, err := Validate(writeTemp(t, samplePDF(3)))
+	if err != nil || n != 3 {
+		t.Errorf("Validate() = %d, %v; want 3 páginas", n, err)
+	}
+
+	n, err = Validate(writeTemp(t, xrefStreamPDF()))
+	if err != nil || n != 2 {
+		t.Errorf("Validate() con xref comprimida = %d, %v; want 2 páginas", n, err)
+	}
+
+	// A broken startxref is rebuilt from the objects in the file
+	data := samplePDF(2)
+	i := bytes.LastIndex(data, []byte("startxref"))
+	broken := append(append([]byte{}, data[:i]...), "startxref\n9\n%%EOF\n"...)
+	n, err = Validate(writeTemp(t, broken))
+	if err != nil || n != 2 {
+		t.Errorf("Validate() con xref dañada = %d, %v; want 2 páginas", n, err)
+	}
+
+	invalid := map[string][]byte{
+		"sin cabecera": []byte("hola mundo"),
+		"truncado":     data[:len(data)/3],
+		"sin páginas":  samplePDF(0),
+	}
+	for name, data := range invalid {
+		if _, err := Validate(writeTemp(t, data)); err == nil {
+			t.Errorf("Validate() %s debería fallar", name)
+		}
+	}
+}
+
+func TestPagesInherit(t *testing.T) {
+	r, err := NewReader(xrefStreamPDF())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Repaired {
+		t.Errorf("la xref comprimida no debería necesitar reconstruirse")
+	}
+	pages, err := r.Pages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, page := range pages {
+		box, ok := page.Dict["MediaBox"].(Array)
+		if !ok || box[2] != 612 {
+			t.Errorf("página %d: MediaBox = %v; want heredado [0 0 612 792]", i+1, page.Dict["MediaBox"])
+		}
+	}
+	if pages[1].Dict["Rotate"] != 90 {
+		t.Errorf("página 2: Rotate = %v; want 90", pages[1].Dict["Rotate"])
+	}
+}
+
+func TestParser(t *testing.T) {
+	p := &parser{data: []byte(`<< /Name#20x (a\(b\)\101) /Hex <4849> /Arr [1 -2.5 3 0 R true null] >>`)}
+	o, err := p.object()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := o.(Dict)
+	if s := string(d["Name x"].(String)); s != "a(b)A" {
+		t.Errorf("string literal = %q; want %q", s, "a(b)A")
+	}
+	if s := string(d["Hex"].(String)); s != "HI" {
+		t.Errorf("string hexadecimal = %q; want %q", s, "HI")
+	}
+	want := Array{1, -2.5, Ref(3), true, nil}
+	if fmt.Sprint(d["Arr"]) != fmt.Sprint(want) {
+		t.Errorf("array = %v; want %v", d["Arr"], want)
+	}
+}
+
+// malformedPDFs breaks the xref and object streams of xrefStreamPDF with
+// values out of range
+func malformedPDFs() map[string][]byte {
+	good := xrefStreamPDF()
+	replace := func(old, new string) []byte {
+		return bytes.Replace(good, []byte(old), []byte(new), 1)
+	}
+	return map[string][]byte{
+		"/W negativo":       replace("/W [1 2 1]", "/W [1 -1 3]"),
+		"/W enorme":         replace("/W [1 2 1]", "/W [1 99 1]"),
+		"/Columns negativo": replace("/Columns 4", "/Columns -4"),
+		"/Columns enorme":   replace("/Columns 4", "/Columns 4000000000000"),
+		"/First negativo":   replace("/First ", "/First -"),
+		"offset negativo":   replace("2 0 3 ", "2 0 3 -"),
+	}
+}
+
+// TestValidateMalformed checks that bad values give an error, or the pages
+// found rebuilding the xref from the objects, instead of a panic
+func TestValidateMalformed(t *testing.T) {
+	for name, data := range malformedPDFs() {
+		if n, err := ValidateData(data); err == nil && n != 2 {
+			t.Errorf("ValidateData() con %s = %d, nil; want 2 páginas or an error", name, n)
+		}
+	}
+}
+
+// FuzzValidateData checks that no document makes the reader panic
+func FuzzValidateData(f *testing.F) {
+	f.Add(samplePDF(2))
+	f.Add(xrefStreamPDF())
+	for _, data := range malformedPDFs() {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ValidateData(data)
+	})
+}
@@ -0,0 +1,145 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+)
+
+// Ref is the number of an indirect object
+type Ref int
+
+func (r Ref) String() string {
+	return fmt.Sprintf("%d 0 R", r)
+}
+
+// Writer builds a pdf in memory from serialized object bodies
+type Writer struct {
+	// Version goes in the header, 1.4 unless the objects need a newer one
+	Version string
+	objects [][]byte
+}
+
+func NewWriter() *Writer {
+	return &Writer{Version: "1.4"}
+}
+
+// Reserve allocates an object number to be filled later with Set
+func (w *Writer) Reserve() Ref {
+	w.objects = append(w.objects, nil)
+	return Ref(len(w.objects))
+}
+
+// Set writes the body of a reserved object
+func (w *Writer) Set(ref Ref, body string) {
+	w.objects[ref-1] = []byte(body)
+}
+
+// Add appends an object and returns its reference
+func (w *Writer) Add(body string) Ref {
+	ref := w.Reserve()
+	w.Set(ref, body)
+	return ref
+}
+
+// AddStream appends a stream object. dict holds the entries besides /Length,
+// without the surrounding << >>.
+func (w *Writer) AddStream(dict string, data []byte) Ref {
+	ref := w.Reserve()
+	w.SetStream(ref, dict, data)
+	return ref
+}
+
+// SetStream writes the body of a reserved stream object
+func (w *Writer) SetStream(ref Ref, dict string, data []byte) {
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "<< %s /Length %d >>\nstream\n", dict, len(data))
+	body.Write(data)
+	body.WriteString("\nendstream")
+	w.objects[ref-1] = body.Bytes()
+}
+
+// AddCompressedStream appends a stream compressed with FlateDecode
+func (w *Writer) AddCompressedStream(dict string, data []byte) Ref {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+	return w.AddStream(strings.TrimSpace(dict+" /Filter /FlateDecode"), compressed.Bytes())
+}
+
+// AddPageTree writes the catalog and a flat page tree over pages, where every
+// page was reserved by the caller and points to parent with /Parent.
+// It returns the catalog, to be passed to Bytes.
+func (w *Writer) AddPageTree(parent Ref, pages []Ref) Ref {
+	kids := make([]string, len(pages))
+	for i, page := range pages {
+		kids[i] = page.String()
+	}
+	w.Set(parent, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	return w.Add(fmt.Sprintf("<< /Type /Catalog /Pages %s >>", parent))
+}
+
+// Bytes serializes the document with a classic xref table
+func (w *Writer) Bytes(catalog Ref) []byte {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%%PDF-%s\n%%\xe2\xe3\xcf\xd3\n", w.Version)
+
+	offsets := make([]int, len(w.objects))
+	for i, body := range w.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		if body == nil {
+			out.WriteString("null")
+		} else {
+			out.Write(body)
+		}
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(w.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %s >>\nstartxref\n%d\n%%%%EOF\n", len(w.objects)+1, catalog, xref)
+	return out.Bytes()
+}
+
+// winAnsi maps the characters outside Latin-1 that WinAnsiEncoding can show
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// Text returns s as a literal string in WinAnsiEncoding, ready for Tj.
+// Characters the standard fonts can't show become '?'.
+func Text(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		var c byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			c = byte(r)
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			c = byte(r)
+		default:
+			var ok bool
+			if c, ok = winAnsi[r]; !ok {
+				c = '?'
+			}
+		}
+		if c >= 0x80 {
+			fmt.Fprintf(&b, "\\%03o", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package queue
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Job is one entry of the lpq listing
+type Job struct {
+	Rank  string
+	Owner string
+	ID    int
+	File  string
+	Size  int64
+}
+
+// Queue is the parsed state of a printer queue
+type Queue struct {
+	Printer string
+	Status  string
+	Jobs    []Job
+}
+
+// Parse reads the output of lpq in its CUPS/BSD format:
+//
+//	hp-335 is ready and printing
+//	Rank    Owner   Job     File(s)                         Total Size
+//	active  juan    123     dccprint-apunte.ps              123456 bytes
+//
+// Lines before the header are kept as the printer status.
+func Parse(output string) Queue {
+	var q Queue
+	var status []string
+	inJobs := false
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !inJobs {
+			if strings.HasPrefix(line, "Rank") {
+				inJobs = true
+				continue
+			}
+			if line != "no entries" {
+				status = append(status, line)
+			}
+			continue
+		}
+		if job, ok := parseJob(line); ok {
+			q.Jobs = append(q.Jobs, job)
+		}
+	}
+
+	q.Status = strings.Join(status, " ")
+	return q
+}
+
+func parseJob(line string) (Job, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return Job{}, false
+	}
+	id, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Job{}, false
+	}
+
+	job := Job{Rank: fields[0], Owner: fields[1], ID: id}
+	files := fields[3:]
+	if n := len(files); n >= 2 && files[n-1] == "bytes" {
+		job.Size, _ = strconv.ParseInt(files[n-2], 10, 64)
+		files = files[:n-2]
+	}
+	job.File = strings.Join(files, " ")
+	return job, true
+}
+
+// Active reports whether the job is the one being printed right now
+func (j Job) Active() bool {
+	return j.Rank == "active"
+}
@@ -0,0 +1,97 @@
+package queue
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	output := `hp-335 is ready and printing
+Rank    Owner   Job     File(s)                         Total Size
+active  juan    123     dccprint-apunte.ps              123456 bytes
+1st     maria   124     Tarea 1.ps                      2048 bytes
+`
+	q := Parse(output)
+	if q.Status != "hp-335 is ready and printing" {
+		t.Errorf("Status = %q", q.Status)
+	}
+	if len(q.Jobs) != 2 {
+		t.Fatalf("len(Jobs) = %d; want 2", len(q.Jobs))
+	}
+
+	want := []Job{
+		{Rank: "active", Owner: "juan", ID: 123, File: "dccprint-apunte.ps", Size: 123456},
+		{Rank: "1st", Owner: "maria", ID: 124, File: "Tarea 1.ps", Size: 2048},
+	}
+	for i, job := range q.Jobs {
+		if job != want[i] {
+			t.Errorf("Jobs[%d] = %+v; want %+v", i, job, want[i])
+		}
+	}
+	if !q.Jobs[0].Active() || q.Jobs[1].Active() {
+		t.Errorf("solo el primer trabajo debería estar activo")
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	q := Parse("hp-335 is ready\nno entries\n")
+	if len(q.Jobs) != 0 {
+		t.Errorf("len(Jobs) = %d; want 0", len(q.Jobs))
+	}
+	if q.Status != "hp-335 is ready" {
+		t.Errorf("Status = %q", q.Status)
+	}
+}
+
+func TestSubmittedID(t *testing.T) {
+	listing := `hp-335 is ready and printing
+Rank    Owner   Job     File(s)                         Total Size
+active  juan    123     dccprint-apunte                 123456 bytes
+1st     alumno  124     dccprint-tarea1                 2048 bytes
+2nd     alumno  130     dccprint-tarea1                 2048 bytes
+3rd     alumno  131     dccprint-otro                   2048 bytes
+`
+	if id, ok := SubmittedID(listing, "alumno", "dccprint-tarea1"); !ok || id != 130 {
+		t.Errorf("SubmittedID() = %d, %v; want 130", id, ok)
+	}
+	if id, ok := SubmittedID("request id is hp-335-77 (1 file(s))\n"+listing, "alumno", "dccprint-tarea1"); !ok || id != 77 {
+		t.Errorf("SubmittedID() con request id = %d, %v; want 77", id, ok)
+	}
+	if _, ok := SubmittedID(listing, "alumno", "dccprint-nada"); ok {
+		t.Errorf("SubmittedID() no debería encontrar un trabajo que no está en la cola")
+	}
+
+	// CUPS cuts the title to the width of the File(s) column
+	long := `hp-335 is ready
+Rank    Owner   Job     File(s)                         Total Size
+1st     alumno  140     dccprint-apuntes-calculo-dife   2048 bytes
+2nd     alumno  141     dccprint-apuntes-algebra-line   2048 bytes
+`
+	if id, ok := SubmittedID(long, "alumno", "dccprint-apuntes-calculo-diferencial-2024"); !ok || id != 140 {
+		t.Errorf("SubmittedID() con título truncado = %d, %v; want 140", id, ok)
+	}
+}
+
+func TestTrack(t *testing.T) {
+	q := Parse(`hp-335 is ready and printing
+Rank    Owner   Job     File(s)                         Total Size
+active  juan    123     dccprint-apunte                 123456 bytes
+1st     alumno  124     dccprint-tarea1                 2048 bytes
+`)
+	cases := []struct {
+		id    int
+		state State
+		ahead int
+	}{
+		{123, StatePrinting, 0},
+		{124, StateQueued, 1},
+		{99, StateDone, 0},
+	}
+	for _, c := range cases {
+		if state, ahead := q.Track(c.id); state != c.state || ahead != c.ahead {
+			t.Errorf("Track(%d) = %v, %d; want %v, %d", c.id, state, ahead, c.state, c.ahead)
+		}
+	}
+
+	q.Status = "hp-335 is not ready"
+	if state, _ := q.Track(124); state != StateStuck {
+		t.Errorf("Track() con la impresora detenida = %v; want %v", state, StateStuck)
+	}
+}
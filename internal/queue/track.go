@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// State is where a submitted job is on its way to paper
+type State int
+
+const (
+	StateQueued State = iota
+	StatePrinting
+	StateDone
+	// StateStuck is a job waiting on a printer that reports a problem
+	StateStuck
+)
+
+func (s State) String() string {
+	switch s {
+	case StatePrinting:
+		return "imprimiendo"
+	case StateDone:
+		return "terminado"
+	case StateStuck:
+		return "detenido"
+	}
+	return "en cola"
+}
+
+// requestIDRe matches the id lp and some lpr print, like "request id is hp-335-123 (1 file(s))"
+var requestIDRe = regexp.MustCompile(`request id is \S+-(\d+)`)
+
+// SubmittedID finds the job just sent in the output of lpr followed by
+// lpq. It takes the id lpr printed or, when it printed none, the newest job
+// of owner named title in the listing. lpq cuts long titles, so a job whose
+// name starts title is taken when none has it whole. It returns false when
+// the job already left the queue or can't be told apart.
+func SubmittedID(output, owner, title string) (int, bool) {
+	if m := requestIDRe.FindStringSubmatch(output); m != nil {
+		id, err := strconv.Atoi(m[1])
+		return id, err == nil
+	}
+	exact, truncated := 0, 0
+	for _, job := range Parse(output).Jobs {
+		if job.Owner != owner {
+			continue
+		}
+		name := strings.TrimSuffix(job.File, "...")
+		switch {
+		case job.File == title:
+			exact = max(exact, job.ID)
+		case name != "" && strings.HasPrefix(title, name):
+			truncated = max(truncated, job.ID)
+		}
+	}
+	if exact > 0 {
+		return exact, true
+	}
+	return truncated, truncated > 0
+}
+
+// problems are words of the lpq status that mean the printer won't go on by itself
+var problems = []string{"error", "not ready", "offline", "off-line", "paused", "disabled", "stopped", "jam", "paper", "toner", "door", "attention"}
+
+// Problem returns the printer status when it reports something to fix
+func (q Queue) Problem() (string, bool) {
+	status := strings.ToLower(q.Status)
+	for _, word := range problems {
+		if strings.Contains(status, word) {
+			return q.Status, true
+		}
+	}
+	return "", false
+}
+
+// Track returns the state of job id and, while it waits, how many jobs are
+// ahead of it. A job missing from the listing has finished.
+func (q Queue) Track(id int) (State, int) {
+	for i, job := range q.Jobs {
+		if job.ID != id {
+			continue
+		}
+		if _, stuck := q.Problem(); stuck {
+			return StateStuck, i
+		}
+		if job.Active() {
+			return StatePrinting, 0
+		}
+		return StateQueued, i
+	}
+	return StateDone, 0
+}
@@ -0,0 +1,77 @@
+package quota
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Command is run in anakena to query the remaining paper
+const Command = "papel"
+
+// Quota is the remaining paper of a DCC account at the time it was checked
+type Quota struct {
+	Remaining int       `json:"remaining"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+var numberRe = regexp.MustCompile(`-?\d+`)
+
+// Words that papel uses next to the remaining amount
+var remainingWords = []string{"quedan", "queda", "restante", "disponible", "saldo"}
+
+// Parse extracts the remaining sheets from the output of papel.
+// Lines that talk about the remaining paper win, otherwise the output
+// must contain a single number.
+func Parse(output string) (Quota, error) {
+	var numbers []string
+	for _, line := range strings.Split(output, "\n") {
+		found := numberRe.FindAllString(line, -1)
+		if len(found) == 0 {
+			continue
+		}
+		if number, ok := remainingNumber(strings.ToLower(line)); ok {
+			return newQuota(number)
+		}
+		numbers = append(numbers, found...)
+	}
+
+	if len(numbers) != 1 {
+		return Quota{}, fmt.Errorf("no se pudo leer la salida de papel: %q", strings.TrimSpace(output))
+	}
+	return newQuota(numbers[0])
+}
+
+// remainingNumber returns the number that follows a word about the remaining
+// paper, as in "quedan 120 de 500", or the one before it when the line ends
+// with the word, as in "312 hojas disponibles"
+func remainingNumber(line string) (string, bool) {
+	for _, word := range remainingWords {
+		i := strings.Index(line, word)
+		if i < 0 {
+			continue
+		}
+		if number := numberRe.FindString(line[i+len(word):]); number != "" {
+			return number, true
+		}
+		if before := numberRe.FindAllString(line[:i], -1); len(before) > 0 {
+			return before[len(before)-1], true
+		}
+	}
+	return "", false
+}
+
+func newQuota(number string) (Quota, error) {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return Quota{}, err
+	}
+	return Quota{Remaining: n, CheckedAt: time.Now()}, nil
+}
+
+// String describes the quota for the main menu
+func (q Quota) String() string {
+	return fmt.Sprintf("Papel restante: %d hojas (consultado %s)", q.Remaining, q.CheckedAt.Format("02/01 15:04"))
+}
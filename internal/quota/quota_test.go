@@ -0,0 +1,33 @@
+package quota
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		output   string
+		expected int
+	}{
+		{"154\n", 154},
+		{"Te quedan 87 hojas de impresión\n", 87},
+		{"Usuario: juan\nCuota 2025: 500\nSaldo restante: 312\n", 312},
+		{"Le quedan -3 hojas\n", -3},
+		{"Te quedan 120 de 500 hojas\n", 120},
+		{"312 hojas disponibles\n", 312},
+	}
+	for _, c := range cases {
+		q, err := Parse(c.output)
+		if err != nil {
+			t.Errorf("Parse(%q) devolvió error: %v", c.output, err)
+			continue
+		}
+		if q.Remaining != c.expected {
+			t.Errorf("Parse(%q) = %d; want %d", c.output, q.Remaining, c.expected)
+		}
+	}
+
+	for _, output := range []string{"", "papel: command not found", "Cuota 500, usadas 20"} {
+		if _, err := Parse(output); err == nil {
+			t.Errorf("Parse(%q) debería fallar", output)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/fgonzalezurriola/dccprint/internal/shell"
+)
+
+// keyNames are the private keys in ~/.ssh that ssh tries by default, in order
+var keyNames = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// DefaultKeyPath is where a new key is generated, the first one ssh looks for
+func DefaultKeyPath() (string, error) {
+	return expandHome("~/.ssh/" + keyNames[0])
+}
+
+// FindKey returns the private key that would be used to log into anakena:
+// the identity of the settings or ~/.ssh/config, or one of the default
+// keys. It returns "" when there is none.
+func FindKey(s Settings) string {
+	_, target := s.resolve(readSSHConfig())
+	candidates := []string{target.identity}
+	for _, name := range keyNames {
+		candidates = append(candidates, "~/.ssh/"+name)
+	}
+	for _, path := range candidates {
+		if path == "" {
+			continue
+		}
+		if path, err := expandHome(path); err == nil {
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// GenerateKey creates an ed25519 key pair without passphrase at path and
+// path.pub, like ssh-keygen -t ed25519 -N "". Existing files are never
+// overwritten.
+func GenerateKey(path, comment string) error {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	block, err := ssh.MarshalPrivateKey(private, comment)
+	if err != nil {
+		return err
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := writeNew(path+".pub", []byte(authorizedKey(sshPublic, comment)+"\n"), 0644); err != nil {
+		return err
+	}
+	if err := writeNew(path, pem.EncodeToMemory(block), 0600); err != nil {
+		os.Remove(path + ".pub")
+		return err
+	}
+	return nil
+}
+
+// writeNew writes data to a file that must not exist yet
+func writeNew(path string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+// LoadKey reads the private key at path, asking its passphrase through p
+func LoadKey(path string, p Prompter) (ssh.Signer, error) {
+	return identitySigner(path, p)
+}
+
+// KeyEncrypted reports whether the private key at path is protected by a
+// passphrase. dccprint only uses such keys through ssh-agent, or when they
+// are the identity of the settings, asking the passphrase on every login.
+func KeyEncrypted(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	_, err = ssh.ParsePrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	return errors.As(err, &missing)
+}
+
+// InAgent reports whether ssh-agent holds key
+func InAgent(key ssh.PublicKey) bool {
+	conn := dialAgent()
+	if conn == nil {
+		return false
+	}
+	defer conn.Close()
+	keys, err := agent.NewClient(conn).List()
+	if err != nil {
+		return false
+	}
+	for _, k := range keys {
+		if bytes.Equal(k.Marshal(), key.Marshal()) {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizedKey returns key as a line of authorized_keys
+func authorizedKey(key ssh.PublicKey, comment string) string {
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+	if comment != "" {
+		line += " " + comment
+	}
+	return line
+}
+
+// installKeyCommand appends key to ~/.ssh/authorized_keys unless it is
+// already there, with the permissions sshd asks for
+func installKeyCommand(key ssh.PublicKey, comment string) string {
+	// The key is looked for without its comment, it may have another one
+	body := shell.Quote(strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))))
+	line := shell.Quote(authorizedKey(key, comment))
+	return `umask 077 && mkdir -p "$HOME/.ssh" && touch "$HOME/.ssh/authorized_keys" && ` +
+		`{ grep -qF -- ` + body + ` "$HOME/.ssh/authorized_keys" || ` +
+		`printf '%s\n' ` + line + ` >> "$HOME/.ssh/authorized_keys"; }`
+}
+
+// InstallKey authorizes key to log into anakena as the user of the connection
+func (c *Client) InstallKey(key ssh.PublicKey, comment string) error {
+	if out, err := c.Output(installKeyCommand(key, comment)); err != nil {
+		return fmt.Errorf("no se pudo agregar la llave a authorized_keys: %w: %s", err, strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// CheckKey logs into anakena again using only signer, through the same
+// jump hosts, to tell whether the key is accepted
+func (c *Client) CheckKey(signer ssh.Signer) error {
+	var via *ssh.Client
+	if len(c.hops) > 0 {
+		via = c.hops[len(c.hops)-1]
+	}
+	conn, err := dialEndpoint(via, c.target, c.user, []ssh.AuthMethod{ssh.PublicKeys(signer)})
+	if err != nil {
+		return fmt.Errorf("%s no acepta la llave: %w", c.name, err)
+	}
+	return conn.Close()
+}
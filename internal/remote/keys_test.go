@@ -0,0 +1,244 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer runs an SSH server that takes the password secreto or the keys
+// in home/.ssh/authorized_keys, and runs commands with sh as if home were $HOME.
+// It presents hostKeys, or a new ed25519 key when none is given.
+func testServer(t *testing.T, home string, hostKeys ...ssh.Signer) int {
+	t.Helper()
+	authorized := func(key ssh.PublicKey) bool {
+		data, _ := os.ReadFile(filepath.Join(home, ".ssh", "authorized_keys"))
+		for len(data) > 0 {
+			k, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+			if err != nil {
+				return false
+			}
+			if bytes.Equal(k.Marshal(), key.Marshal()) {
+				return true
+			}
+			data = rest
+		}
+		return false
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == "secreto" {
+				return nil, nil
+			}
+			return nil, errors.New("contraseña incorrecta")
+		},
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if authorized(key) {
+				return nil, nil
+			}
+			return nil, errors.New("llave desconocida")
+		},
+	}
+	if len(hostKeys) == 0 {
+		_, hostKey, _ := ed25519.GenerateKey(rand.Reader)
+		signer, err := ssh.NewSignerFromKey(hostKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hostKeys = append(hostKeys, signer)
+	}
+	for _, signer := range hostKeys {
+		config.AddHostKey(signer)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(netConn, config, home)
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func serveConn(netConn net.Conn, config *ssh.ServerConfig, home string) {
+	_, chans, reqs, err := ssh.NewServerConn(netConn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer channel.Close()
+			for req := range requests {
+				var exec struct{ Command string }
+				if req.Type != "exec" || ssh.Unmarshal(req.Payload, &exec) != nil {
+					req.Reply(false, nil)
+					continue
+				}
+				req.Reply(true, nil)
+				status := runShell(exec.Command, home, channel)
+				channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				return
+			}
+		}()
+	}
+}
+
+func runShell(command, home string, channel ssh.Channel) uint32 {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = []string{"HOME=" + home, "PATH=" + os.Getenv("PATH")}
+	cmd.Stdout, cmd.Stderr = channel, channel.Stderr()
+	if err := cmd.Run(); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// passwordPrompter answers every prompt with password and counts them
+type passwordPrompter struct {
+	password string
+	asked    int
+}
+
+func (p *passwordPrompter) Prompt(string, bool) (string, error) {
+	p.asked++
+	if p.password == "" {
+		return "", ErrCancelled
+	}
+	return p.password, nil
+}
+
+func TestKeySetup(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh no está disponible")
+	}
+	home, serverHome := t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+	settings := Settings{Host: "127.0.0.1", Port: testServer(t, serverHome)}
+
+	if path := FindKey(settings); path != "" {
+		t.Fatalf("FindKey() = %q sin llaves; want \"\"", path)
+	}
+	path, err := DefaultKeyPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateKey(path, "dccprint@prueba"); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateKey(path, "dccprint@prueba"); !errors.Is(err, os.ErrExist) {
+		t.Errorf("GenerateKey() sobre una llave = %v; want os.ErrExist", err)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("permisos de la llave = %v; want 0600", info.Mode().Perm())
+	}
+	if found := FindKey(settings); found != path {
+		t.Errorf("FindKey() = %q; want %q", found, path)
+	}
+	signer, err := LoadKey(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := os.ReadFile(path + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := authorizedKey(signer.PublicKey(), "dccprint@prueba") + "\n"; string(pub) != want {
+		t.Errorf("%s.pub = %q; want %q", path, pub, want)
+	}
+
+	p := &passwordPrompter{password: "secreto"}
+	client, err := Dial(settings, "alumno", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.CheckKey(signer); err == nil {
+		t.Error("CheckKey() = nil antes de instalar la llave")
+	}
+	for range 2 {
+		if err := client.InstallKey(signer.PublicKey(), "dccprint@prueba"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.CheckKey(signer); err != nil {
+		t.Errorf("CheckKey() = %v después de instalar la llave", err)
+	}
+	if p.asked != 1 {
+		t.Errorf("se pidió la contraseña %d veces; want 1", p.asked)
+	}
+
+	data, err := os.ReadFile(filepath.Join(serverHome, ".ssh", "authorized_keys"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 1 {
+		t.Errorf("authorized_keys tiene %d líneas; want 1:\n%s", n, data)
+	}
+	if info, err := os.Stat(filepath.Join(serverHome, ".ssh")); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0700 {
+		t.Errorf("permisos de ~/.ssh = %v; want 0700", info.Mode().Perm())
+	}
+
+	// The next connections don't ask for the password
+	again, err := Dial(settings, "alumno", &passwordPrompter{})
+	if err != nil {
+		t.Fatalf("Dial() con la llave = %v", err)
+	}
+	again.Close()
+}
+
+func TestKeyEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	plain, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked, err := ssh.MarshalPrivateKeyWithPassphrase(private, "", []byte("frase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, block := range map[string]*pem.Block{"plain": plain, "locked": locked} {
+		if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if KeyEncrypted(filepath.Join(dir, "plain")) {
+		t.Error("KeyEncrypted() = true para una llave sin frase")
+	}
+	if !KeyEncrypted(filepath.Join(dir, "locked")) {
+		t.Error("KeyEncrypted() = false para una llave con frase")
+	}
+	t.Setenv("SSH_AUTH_SOCK", "")
+	signer, _ := ssh.NewSignerFromKey(private)
+	if InAgent(signer.PublicKey()) {
+		t.Error("InAgent() = true sin ssh-agent")
+	}
+}
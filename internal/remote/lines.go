@@ -0,0 +1,46 @@
+package remote
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// LineWriter splits everything written to it into lines and hands them to fn.
+// It is used to stream remote output into the TUI.
+type LineWriter struct {
+	mu  sync.Mutex
+	fn  func(string)
+	buf []byte
+}
+
+func NewLineWriter(fn func(string)) *LineWriter {
+	return &LineWriter{fn: fn}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(strings.TrimRight(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush sends the last line when the output did not end with a newline
+func (w *LineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.fn(strings.TrimRight(string(w.buf), "\r"))
+		w.buf = nil
+	}
+}
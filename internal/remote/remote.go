@@ -0,0 +1,518 @@
+package remote
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// DefaultHost is the DCC server that owns the printers
+const (
+	DefaultHost = "anakena.dcc.uchile.cl"
+	DefaultPort = 22
+)
+
+// Settings say how to reach anakena. Empty fields are taken from the entry
+// of Host in ~/.ssh/config, like ssh does, or from the defaults.
+type Settings struct {
+	Host string
+	Port int
+	// IdentityFile is a private key tried before the default ones
+	IdentityFile string
+	// JumpHost is a ProxyJump spec, [user@]host[:port], or several separated by commas
+	JumpHost string
+}
+
+// Name returns the host as the user wrote it, anakena by default
+func (s Settings) Name() string {
+	if s.Host == "" {
+		return DefaultHost
+	}
+	return s.Host
+}
+
+// Command returns the ssh invocation that logs in as user. Only the settings
+// given are passed, ssh reads ~/.ssh/config by itself.
+func (s Settings) Command(user string) []string {
+	argv := []string{"ssh"}
+	if s.Port != 0 {
+		argv = append(argv, "-p", strconv.Itoa(s.Port))
+	}
+	if s.IdentityFile != "" {
+		argv = append(argv, "-i", s.IdentityFile)
+	}
+	if s.JumpHost != "" {
+		argv = append(argv, "-J", s.JumpHost)
+	}
+	return append(argv, user+"@"+s.Name())
+}
+
+var (
+	hostRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	jumpRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@:/,-]*$`)
+)
+
+// Validate checks the settings before they reach the ssh command line,
+// where a value starting with - would be read as an option
+func (s Settings) Validate() error {
+	if s.Host != "" && !hostRe.MatchString(s.Host) {
+		return fmt.Errorf("servidor inválido: %q", s.Host)
+	}
+	if s.Port < 0 || s.Port > 65535 {
+		return fmt.Errorf("puerto inválido: %d (usa un número entre 1 y 65535)", s.Port)
+	}
+	if s.JumpHost != "" && !jumpRe.MatchString(s.JumpHost) {
+		return fmt.Errorf("salto inválido: %q (usa [usuario@]servidor[:puerto], separados por comas)", s.JumpHost)
+	}
+	return nil
+}
+
+// endpoint is a host resolved with ~/.ssh/config, ready to dial
+type endpoint struct {
+	name     string
+	user     string
+	addr     string
+	identity string
+}
+
+// resolve completes the settings with the entries of sshConfig and returns
+// the jump hosts, in order, and anakena
+func (s Settings) resolve(sshConfig []byte) (jumps []endpoint, target endpoint) {
+	entry := lookupSSHConfig(sshConfig, s.Name())
+	// The DCC account always logs into anakena, User only applies to the jump hosts
+	target = newEndpoint(s.Name(), entry)
+	target.user = ""
+	if s.Port != 0 {
+		target.addr = net.JoinHostPort(hostOf(target.addr), strconv.Itoa(s.Port))
+	}
+	if s.IdentityFile != "" {
+		target.identity = s.IdentityFile
+	}
+
+	spec := s.JumpHost
+	if spec == "" {
+		spec = entry.ProxyJump
+	}
+	for _, hop := range strings.Split(spec, ",") {
+		if hop = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(hop), "ssh://")); hop == "" {
+			continue
+		}
+		user, hostPort := "", hop
+		if u, h, ok := strings.Cut(hop, "@"); ok {
+			user, hostPort = u, h
+		}
+		host, port := hostPort, ""
+		if h, p, err := net.SplitHostPort(hostPort); err == nil {
+			host, port = h, p
+		}
+		e := newEndpoint(host, lookupSSHConfig(sshConfig, host))
+		if user != "" {
+			e.user = user
+		}
+		if port != "" {
+			e.addr = net.JoinHostPort(hostOf(e.addr), port)
+		}
+		jumps = append(jumps, e)
+	}
+	return jumps, target
+}
+
+func newEndpoint(alias string, entry hostConfig) endpoint {
+	host, port := alias, DefaultPort
+	if entry.HostName != "" {
+		host = entry.HostName
+	}
+	if entry.Port != 0 {
+		port = entry.Port
+	}
+	return endpoint{name: alias, user: entry.User, addr: net.JoinHostPort(host, strconv.Itoa(port)), identity: entry.IdentityFile}
+}
+
+func hostOf(addr string) string {
+	host, _, _ := net.SplitHostPort(addr)
+	return host
+}
+
+// ErrCancelled is returned when the user dismisses a password prompt
+var ErrCancelled = errors.New("autenticación cancelada")
+
+// Prompter answers the password and keyboard-interactive challenges of the server.
+// echo is false for secrets that must be masked.
+type Prompter interface {
+	Prompt(question string, echo bool) (string, error)
+}
+
+// Client is an authenticated SSH connection to anakena
+type Client struct {
+	conn *ssh.Client
+	// hops are the jump hosts the connection goes through
+	hops []*ssh.Client
+	name string
+	user string
+	// target is anakena as resolved when dialing, to log in again with CheckKey
+	target endpoint
+	// agent is the connection to ssh-agent, nil when there is none
+	agent net.Conn
+}
+
+// Dial opens an SSH connection to anakena as user, through the jump hosts
+// of s. Keys from the identity file, ssh-agent and ~/.ssh are tried first,
+// then the password is asked through p.
+func Dial(s Settings, user string, p Prompter) (*Client, error) {
+	jumps, target := s.resolve(readSSHConfig())
+	client := &Client{name: s.Name(), target: target, agent: dialAgent()}
+	var via *ssh.Client
+	for _, hop := range append(jumps, target) {
+		conn, err := dialEndpoint(via, hop, user, authMethods(hop.identity, client.agent, p))
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("no se pudo conectar a %s: %w", hop.name, err)
+		}
+		client.hops = append(client.hops, conn)
+		via = conn
+	}
+	client.conn = via
+	client.hops = client.hops[:len(client.hops)-1]
+	client.user = user
+	return client, nil
+}
+
+// dialEndpoint logs into e with auth, directly or through the connection via
+func dialEndpoint(via *ssh.Client, e endpoint, user string, auth []ssh.AuthMethod) (*ssh.Client, error) {
+	if e.user != "" {
+		user = e.user
+	}
+	config := &ssh.ClientConfig{
+		User:              user,
+		Auth:              auth,
+		HostKeyCallback:   hostKeyCallback(e.name),
+		HostKeyAlgorithms: knownHostAlgorithms(e.addr),
+		Timeout:           10 * time.Second,
+	}
+	if via == nil {
+		return ssh.Dial("tcp", e.addr, config)
+	}
+	netConn, err := via.Dial("tcp", e.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, chans, reqs, err := ssh.NewClientConn(netConn, e.addr, config)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(conn, chans, reqs), nil
+}
+
+// Run executes command in anakena, feeding it stdin and copying its output to out
+func (c *Client) Run(command string, stdin io.Reader, out io.Writer) error {
+	session, err := c.conn.NewSession()
+	if err != nil {
+		return fmt.Errorf("no se pudo abrir la sesión SSH: %w", err)
+	}
+	defer session.Close()
+
+	session.Stdin = stdin
+	session.Stdout = out
+	session.Stderr = out
+	return session.Run(command)
+}
+
+// Output executes command in anakena and returns its combined output
+func (c *Client) Output(command string) (string, error) {
+	var out strings.Builder
+	err := c.Run(command, nil, &out)
+	return out.String(), err
+}
+
+// Host returns the name of the server, as the settings gave it
+func (c *Client) Host() string {
+	return c.name
+}
+
+// Alive reports whether the connection still answers requests
+func (c *Client) Alive() bool {
+	_, _, err := c.conn.SendRequest("keepalive@openssh.com", true, nil)
+	return err == nil
+}
+
+// Close ends the session with anakena and then with the jump hosts
+func (c *Client) Close() error {
+	var err error
+	if c.conn != nil {
+		err = c.conn.Close()
+	}
+	for i := len(c.hops) - 1; i >= 0; i-- {
+		c.hops[i].Close()
+	}
+	if c.agent != nil {
+		c.agent.Close()
+	}
+	return err
+}
+
+// Conn lazily dials anakena and shares the connection between jobs,
+// so the password is asked only once per session.
+type Conn struct {
+	mu       sync.Mutex
+	prompter Prompter
+	settings Settings
+	user     string
+	client   *Client
+}
+
+func NewConn(p Prompter) *Conn {
+	return &Conn{prompter: p}
+}
+
+// Client returns the open connection for user, dialing again if it was closed
+// or the settings changed
+func (c *Conn) Client(s Settings, user string) (*Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client != nil && c.user == user && c.settings == s && c.client.Alive() {
+		return c.client, nil
+	}
+	if c.client != nil {
+		c.client.Close()
+		c.client = nil
+	}
+
+	client, err := Dial(s, user, c.prompter)
+	if err != nil {
+		return nil, err
+	}
+	c.client = client
+	c.settings = s
+	c.user = user
+	return client, nil
+}
+
+// Open returns the connection for user if it is still open, without
+// dialing, so background checks never ask for the password
+func (c *Conn) Open(s Settings, user string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil && c.user == user && c.settings == s && c.client.Alive() {
+		return c.client
+	}
+	return nil
+}
+
+func (c *Conn) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.client != nil {
+		c.client.Close()
+		c.client = nil
+	}
+}
+
+// dialAgent connects to ssh-agent, returning nil when it isn't running
+func dialAgent() net.Conn {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil
+	}
+	return conn
+}
+
+// authMethods tries the identity, the keys of ssh-agent through agentConn,
+// if any, and the default keys before asking through p
+func authMethods(identity string, agentConn net.Conn, p Prompter) []ssh.AuthMethod {
+	var methods []ssh.AuthMethod
+
+	if identity != "" {
+		if signer, err := identitySigner(identity, p); err == nil {
+			methods = append(methods, ssh.PublicKeys(signer))
+		}
+	}
+
+	if agentConn != nil {
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(agentConn).Signers))
+	}
+	if signers := defaultSigners(); len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	methods = append(methods,
+		ssh.RetryableAuthMethod(ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i, q := range questions {
+				answer, err := p.Prompt(q, echos[i])
+				if err != nil {
+					return nil, err
+				}
+				answers[i] = answer
+			}
+			return answers, nil
+		}), 3),
+		ssh.RetryableAuthMethod(ssh.PasswordCallback(func() (string, error) {
+			return p.Prompt("Contraseña DCC:", false)
+		}), 3),
+	)
+	return methods
+}
+
+// expandHome replaces a leading ~/ in path with the home directory
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// identitySigner loads the private key at path, asking its passphrase through p
+func identitySigner(path string, p Prompter) (ssh.Signer, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		return signer, err
+	}
+	passphrase, err := p.Prompt(fmt.Sprintf("Frase de la llave %s:", filepath.Base(path)), false)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
+}
+
+// defaultSigners loads the unencrypted private keys that ssh would try by default
+func defaultSigners() []ssh.Signer {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	var signers []ssh.Signer
+	for _, name := range keyNames {
+		data, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			// Passphrase protected keys are left to ssh-agent
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers
+}
+
+// hostKeyCallback checks ~/.ssh/known_hosts and trusts name on first use,
+// but refuses to connect if the stored key changed.
+func hostKeyCallback(name string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		path, err := knownHostsPath()
+		if err != nil {
+			return err
+		}
+
+		check, err := knownhosts.New(path)
+		if err != nil {
+			return addKnownHost(path, hostname, key)
+		}
+
+		err = check(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return addKnownHost(path, hostname, key)
+		}
+		if err != nil {
+			return fmt.Errorf("la llave de %s no coincide con known_hosts: %w", name, err)
+		}
+		return nil
+	}
+}
+
+// knownHostAlgorithms returns the algorithms of the keys known_hosts holds
+// for addr, so the server presents one of those and not a key of another
+// type, which would look like a changed key. It returns nil for a host not
+// known yet, letting the server choose.
+func knownHostAlgorithms(addr string) []string {
+	path, err := knownHostsPath()
+	if err != nil {
+		return nil
+	}
+	check, err := knownhosts.New(path)
+	if err != nil {
+		return nil
+	}
+	// A key no host has makes the check list the known ones
+	placeholder, err := ssh.NewPublicKey(ed25519.PublicKey(make([]byte, ed25519.PublicKeySize)))
+	if err != nil {
+		return nil
+	}
+	var keyErr *knownhosts.KeyError
+	if !errors.As(check(addr, &net.TCPAddr{IP: net.IPv4zero}, placeholder), &keyErr) {
+		return nil
+	}
+	var algorithms []string
+	for _, known := range keyErr.Want {
+		types := []string{known.Key.Type()}
+		if types[0] == ssh.KeyAlgoRSA {
+			types = []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+		}
+		for _, t := range types {
+			if !slices.Contains(algorithms, t) {
+				algorithms = append(algorithms, t)
+			}
+		}
+	}
+	return algorithms
+}
+
+func knownHostsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ssh", "known_hosts"), nil
+}
+
+func addKnownHost(path, hostname string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)
+	_, err = fmt.Fprintln(file, line)
+	return err
+}
@@ -0,0 +1,125 @@
+package remote
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// TestKnownHostType dials a server that has an ecdsa and an ed25519 key
+// while known_hosts only has the ed25519 one. The client would rather use
+// ecdsa, which must not be taken for a changed key.
+func TestKnownHostType(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecSigner, err := ssh.NewSignerFromKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edSigner, err := ssh.NewSignerFromKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := testServer(t, t.TempDir(), ecSigner, edSigner)
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+
+	if got := knownHostAlgorithms(addr); got != nil {
+		t.Errorf("knownHostAlgorithms() sin known_hosts = %v; want nil", got)
+	}
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, edSigner.PublicKey())
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := knownHostAlgorithms(addr); len(got) != 1 || got[0] != ssh.KeyAlgoED25519 {
+		t.Errorf("knownHostAlgorithms() = %v; want [%s]", got, ssh.KeyAlgoED25519)
+	}
+
+	client, err := Dial(Settings{Host: "127.0.0.1", Port: port}, "alumno", &passwordPrompter{password: "secreto"})
+	if err != nil {
+		t.Fatalf("Dial() = %v", err)
+	}
+	client.Close()
+}
+
+// TestAgentClosed checks that closing the client also closes its
+// connection to ssh-agent
+func TestAgentClosed(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skip("no se pueden crear sockets unix: ", err)
+	}
+	defer listener.Close()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	served := make(chan struct{})
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		agent.ServeAgent(agent.NewKeyring(), conn)
+		close(served)
+	}()
+
+	port := testServer(t, t.TempDir())
+	client, err := Dial(Settings{Host: "127.0.0.1", Port: port}, "alumno", &passwordPrompter{password: "secreto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Error("la conexión con ssh-agent sigue abierta después de Close()")
+	}
+}
+
+// TestConnOpen checks that Open hands out the live connection and never dials
+func TestConnOpen(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SSH_AUTH_SOCK", "")
+	settings := Settings{Host: "127.0.0.1", Port: testServer(t, t.TempDir())}
+	p := &passwordPrompter{password: "secreto"}
+	conn := NewConn(p)
+
+	if conn.Open(settings, "alumno") != nil {
+		t.Error("Open() antes de conectar != nil")
+	}
+	client, err := conn.Client(settings, "alumno")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conn.Open(settings, "alumno"); got != client {
+		t.Errorf("Open() = %p; want the open client %p", got, client)
+	}
+	if conn.Open(settings, "otro") != nil {
+		t.Error("Open() con otra cuenta != nil")
+	}
+	conn.Close()
+	if conn.Open(settings, "alumno") != nil {
+		t.Error("Open() después de Close() != nil")
+	}
+	if p.asked != 1 {
+		t.Errorf("se pidió la contraseña %d veces; want 1", p.asked)
+	}
+}
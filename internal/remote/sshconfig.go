@@ -0,0 +1,111 @@
+package remote
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hostConfig holds the entries of ~/.ssh/config that dccprint understands.
+// Empty fields weren't set for the host.
+type hostConfig struct {
+	HostName     string
+	User         string
+	Port         int
+	IdentityFile string
+	ProxyJump    string
+}
+
+// readSSHConfig reads ~/.ssh/config, nil when there is none
+func readSSHConfig() []byte {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".ssh", "config"))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// lookupSSHConfig returns the settings of alias in data, in the format of
+// ssh_config: the first value found for each keyword wins and Host lines
+// take patterns with * and ?, negated with !. Match blocks are skipped.
+func lookupSSHConfig(data []byte, alias string) hostConfig {
+	var c hostConfig
+	matching := true
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		key, value := splitSSHConfigLine(scanner.Text())
+		switch key {
+		case "":
+			continue
+		case "host":
+			matching = matchHost(strings.Fields(value), alias)
+			continue
+		case "match":
+			matching = false
+			continue
+		}
+		if !matching {
+			continue
+		}
+		switch key {
+		case "hostname":
+			if c.HostName == "" {
+				c.HostName = strings.ReplaceAll(value, "%h", alias)
+			}
+		case "user":
+			if c.User == "" {
+				c.User = value
+			}
+		case "port":
+			if n, err := strconv.Atoi(value); err == nil && c.Port == 0 {
+				c.Port = n
+			}
+		case "identityfile":
+			if c.IdentityFile == "" {
+				c.IdentityFile = value
+			}
+		case "proxyjump":
+			if c.ProxyJump == "" && value != "none" {
+				c.ProxyJump = value
+			}
+		}
+	}
+	return c
+}
+
+// splitSSHConfigLine returns the lowercased keyword of a line and its value,
+// which can follow a space or an =
+func splitSSHConfigLine(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+	key, value, _ := strings.Cut(line, " ")
+	if k, v, ok := strings.Cut(key, "="); ok {
+		key, value = k, v+" "+value
+	}
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(value), "="))
+	return strings.ToLower(key), strings.Trim(value, `"`)
+}
+
+// matchHost reports whether alias matches the patterns of a Host line. A
+// matching negated pattern excludes the host whatever the others say.
+func matchHost(patterns []string, alias string) bool {
+	matched := false
+	for _, p := range patterns {
+		negated := strings.HasPrefix(p, "!")
+		ok, _ := path.Match(strings.ToLower(strings.TrimPrefix(p, "!")), strings.ToLower(alias))
+		if ok && negated {
+			return false
+		}
+		matched = matched || ok
+	}
+	return matched
+}
@@ -0,0 +1,110 @@
+package remote
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSSHConfig = `
+# Fuera del DCC
+Host anakena
+    HostName anakena.dcc.uchile.cl
+    ProxyJump alumno@gate.example.cl:2222
+    IdentityFile ~/.ssh/id_dcc
+
+Host *.dcc.uchile.cl !gate.dcc.uchile.cl
+    Port 2200
+
+Match exec "true"
+    Port 1
+
+Host=gate
+    HostName=gate.example.cl
+    User alumno
+
+Host *
+    IdentityFile ~/.ssh/id_default
+    Port 22
+`
+
+func TestLookupSSHConfig(t *testing.T) {
+	tests := []struct {
+		alias string
+		want  hostConfig
+	}{
+		{"anakena", hostConfig{HostName: "anakena.dcc.uchile.cl", Port: 22, IdentityFile: "~/.ssh/id_dcc", ProxyJump: "alumno@gate.example.cl:2222"}},
+		{"anakena.dcc.uchile.cl", hostConfig{Port: 2200, IdentityFile: "~/.ssh/id_default"}},
+		{"gate.dcc.uchile.cl", hostConfig{Port: 22, IdentityFile: "~/.ssh/id_default"}},
+		{"gate", hostConfig{HostName: "gate.example.cl", User: "alumno", Port: 22, IdentityFile: "~/.ssh/id_default"}},
+	}
+	for _, tt := range tests {
+		if got := lookupSSHConfig([]byte(testSSHConfig), tt.alias); got != tt.want {
+			t.Errorf("lookupSSHConfig(%q) = %+v; want %+v", tt.alias, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	jumps, target := Settings{Host: "anakena"}.resolve([]byte(testSSHConfig))
+	wantTarget := endpoint{name: "anakena", addr: "anakena.dcc.uchile.cl:22", identity: "~/.ssh/id_dcc"}
+	if target != wantTarget {
+		t.Errorf("target = %+v; want %+v", target, wantTarget)
+	}
+	wantJumps := []endpoint{{name: "gate.example.cl", user: "alumno", addr: "gate.example.cl:2222", identity: "~/.ssh/id_default"}}
+	if !reflect.DeepEqual(jumps, wantJumps) {
+		t.Errorf("jumps = %+v; want %+v", jumps, wantJumps)
+	}
+
+	// The settings win over ~/.ssh/config
+	s := Settings{Host: "anakena", Port: 2022, IdentityFile: "~/.ssh/otra", JumpHost: "gate, otro@bastion:23"}
+	jumps, target = s.resolve([]byte(testSSHConfig))
+	wantTarget = endpoint{name: "anakena", addr: "anakena.dcc.uchile.cl:2022", identity: "~/.ssh/otra"}
+	if target != wantTarget {
+		t.Errorf("target = %+v; want %+v", target, wantTarget)
+	}
+	wantJumps = []endpoint{
+		{name: "gate", user: "alumno", addr: "gate.example.cl:22", identity: "~/.ssh/id_default"},
+		{name: "bastion", user: "otro", addr: "bastion:23", identity: "~/.ssh/id_default"},
+	}
+	if !reflect.DeepEqual(jumps, wantJumps) {
+		t.Errorf("jumps = %+v; want %+v", jumps, wantJumps)
+	}
+
+	// Without settings nor config, anakena is dialed directly
+	jumps, target = Settings{}.resolve(nil)
+	if len(jumps) != 0 || target.addr != DefaultHost+":22" {
+		t.Errorf("resolve() = %+v, %+v; want %s:22 directly", jumps, target, DefaultHost)
+	}
+}
+
+func TestSettingsCommand(t *testing.T) {
+	tests := []struct {
+		settings Settings
+		want     string
+	}{
+		{Settings{}, "ssh alumno@" + DefaultHost},
+		{Settings{Host: "anakena"}, "ssh alumno@anakena"},
+		{Settings{Host: "anakena", Port: 2022, IdentityFile: "~/.ssh/id_dcc", JumpHost: "gate"}, "ssh -p 2022 -i ~/.ssh/id_dcc -J gate alumno@anakena"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.settings.Command("alumno"), " "); got != tt.want {
+			t.Errorf("%+v.Command() = %q; want %q", tt.settings, got, tt.want)
+		}
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	valid := []Settings{{}, {Host: "anakena", Port: 22, JumpHost: "alumno@gate.example.cl:2222,bastion"}, {Port: 65535}}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("%+v.Validate() = %v; want nil", s, err)
+		}
+	}
+	invalid := []Settings{{Host: "-oProxyCommand=id"}, {Host: "ana kena"}, {Port: -1}, {Port: 65536}, {JumpHost: "-oProxyCommand=id"}, {JumpHost: "gate;id"}}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("%+v.Validate() = nil; want an error", s)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Package shell builds command lines for the POSIX shells run locally by the
+// generated scripts and remotely by ssh in anakena
+package shell
+
+import (
+	"regexp"
+	"strings"
+)
+
+// safe matches the words that read the same with or without quotes
+var safe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// Quote returns s as a single shell word. Words that need it are put in
+// single quotes, where nothing is expanded, and each single quote they
+// hold closes the quotes, is escaped with a backslash and opens them again.
+func Quote(s string) string {
+	if safe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join quotes every argument and joins them in a command line
+func Join(argv ...string) string {
+	words := make([]string, len(argv))
+	for i, arg := range argv {
+		words[i] = Quote(arg)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,52 @@
+package shell
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	cases := map[string]string{
+		"tarea1.pdf":       "tarea1.pdf",
+		"alumno@anakena":   "alumno@anakena",
+		"":                 "''",
+		"Tarea 1.pdf":      "'Tarea 1.pdf'",
+		"$(rm -rf ~).pdf":  "'$(rm -rf ~).pdf'",
+		"it's.pdf":         `'it'\''s.pdf'`,
+		"`id`":             "'`id`'",
+		"-rf":              "-rf",
+		"línea\nnueva.pdf": "'línea\nnueva.pdf'",
+	}
+	for input, want := range cases {
+		if got := Quote(input); got != want {
+			t.Errorf("Quote(%q) = %s; want %s", input, got, want)
+		}
+	}
+}
+
+// FuzzQuote runs the quoted words in sh and checks they come back unchanged,
+// so nothing in them is expanded or run
+func FuzzQuote(f *testing.F) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		f.Skip("no hay sh")
+	}
+	for _, seed := range []string{"", "a b", "'", `"$HOME"`, "`id`", "$(id)", "a;b|c&d", "\\", "'\\''", "\n", "*", "~"} {
+		f.Add(seed, "x")
+	}
+	f.Fuzz(func(t *testing.T, a, b string) {
+		// Arguments can't hold NUL, the output uses it as separator
+		if strings.ContainsRune(a+b, 0) {
+			t.Skip()
+		}
+		out, err := exec.Command(sh, "-c", "printf '%s\\0' "+Join(a, b)).Output()
+		if err != nil {
+			t.Fatalf("sh con %q %q: %v", a, b, err)
+		}
+		if want := []byte(a + "\x00" + b + "\x00"); !bytes.Equal(out, want) {
+			t.Errorf("Join(%q, %q) = %s, sh lo leyó como %q", a, b, Join(a, b), out)
+		}
+	})
+}
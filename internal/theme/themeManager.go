@@ -12,7 +12,7 @@ func NewManager(current string) *Manager {
 	return &Manager{Current: current}
 }
 
-func (tm *Manager) ChangeTheme(selected string) {
-	config.SaveTheme(selected)
+func (tm *Manager) ChangeTheme(selected string) error {
 	tm.Current = selected
+	return config.SaveTheme(selected)
 }